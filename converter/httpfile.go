@@ -0,0 +1,331 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnknownGroup = fmt.Errorf("Unknown group")
+)
+
+const httpBoundary = "MadelyneBoundary"
+
+var (
+	httpVariableRegexp    = regexp.MustCompile(`^@([A-Za-z0-9_.\-]+)\s*=\s*(.*)$`)
+	httpNameRegexp        = regexp.MustCompile(`^(?:#|//)\s*@name\s+(.+)$`)
+	httpRequestLineRegexp = regexp.MustCompile(`^(?:([A-Z]+)\s+)?(\S+)(?:\s+HTTP/[0-9.]+)?$`)
+	httpHeaderRegexp      = regexp.MustCompile(`^([^:\s]+)\s*:\s*(.*)$`)
+	madelyneVarRegexp     = regexp.MustCompile(`#([A-Za-z0-9_.\-]+)#`)
+)
+
+type httpImporter struct {
+	importer
+	readFile func(path string) ([]byte, error)
+}
+
+// ImportHttp converts a .http file (VS Code REST Client / JetBrains HTTP client
+// format) into a suite of one group. readFile loads the bodies given as `< path`.
+func ImportHttp(group string, data []byte, readFile func(path string) ([]byte, error)) (Suite, []string, error) {
+	hi := &httpImporter{
+		importer: newImporter(),
+		readFile: readFile,
+	}
+	g := Group{
+		Name:      Slug(group),
+		TestFile:  Slug(group) + ".yml",
+		UnitTests: []Test{},
+		Scenarios: []Scenario{},
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	blocks := [][]string{}
+	names := []string{}
+	current := []string{}
+	currentName := ""
+	for _, line := range lines {
+		if strings.HasPrefix(line, "###") {
+			blocks = append(blocks, current)
+			names = append(names, currentName)
+			current = []string{}
+			currentName = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+		current = append(current, line)
+	}
+	blocks = append(blocks, current)
+	names = append(names, currentName)
+
+	for i, block := range blocks {
+		t, ok := hi.parseBlock(names[i], block, len(g.UnitTests)+1)
+		if !ok {
+			continue
+		}
+		g.UnitTests = append(g.UnitTests, t)
+	}
+	g.Environment = hi.environment()
+	if len(g.UnitTests) > 0 {
+		hi.report = append(hi.report, "requests of .http files have no expected status, 200 is expected")
+	}
+
+	suite := Suite{
+		Url:    hi.suiteUrl(),
+		Groups: []Group{g},
+	}
+	return suite, hi.report, nil
+}
+
+func (hi *httpImporter) parseBlock(name string, lines []string, index int) (Test, bool) {
+	t := Test{
+		Name:    name,
+		Headers: map[string][]string{},
+		Status:  200,
+	}
+	i := 0
+	requestLine := ""
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if m := httpVariableRegexp.FindStringSubmatch(line); m != nil {
+			hi.variables[m[1]] = m[2]
+			continue
+		}
+		if m := httpNameRegexp.FindStringSubmatch(line); m != nil {
+			t.Name = strings.TrimSpace(m[1])
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		requestLine = line
+		i++
+		break
+	}
+	if requestLine == "" {
+		return Test{}, false
+	}
+	if t.Name == "" {
+		t.Name = fmt.Sprintf("request_%d", index)
+	}
+
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "?") && !strings.HasPrefix(line, "&") {
+			break
+		}
+		requestLine += line
+	}
+
+	m := httpRequestLineRegexp.FindStringSubmatch(requestLine)
+	if m == nil {
+		hi.report = append(hi.report, fmt.Sprintf("%s : request line can't be converted : %s", t.Name, requestLine))
+		return Test{}, false
+	}
+	t.Action = m[1]
+	if t.Action == "" {
+		t.Action = "GET"
+	}
+	u, ok := hi.convertUrl(t.Name, m[2])
+	if !ok {
+		return Test{}, false
+	}
+	t.Url = u
+
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			i++
+			break
+		}
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		h := httpHeaderRegexp.FindStringSubmatch(line)
+		if h == nil {
+			hi.report = append(hi.report, fmt.Sprintf("%s : header line can't be converted : %s", t.Name, line))
+			continue
+		}
+		t.addHeader(h[1], hi.convertVariables(t.Name, h[2]))
+	}
+
+	body := []string{}
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "> ") || strings.HasPrefix(line, "<> ") {
+			hi.report = append(hi.report, fmt.Sprintf("%s : response handler can't be converted : %s", t.Name, strings.TrimSpace(line)))
+			break
+		}
+		body = append(body, line)
+	}
+	content := strings.TrimSpace(strings.Join(body, "\n"))
+	if content == "" {
+		return t, true
+	}
+
+	if strings.HasPrefix(content, "< ") && !strings.Contains(content, "\n") {
+		path := strings.TrimSpace(content[2:])
+		data, err := hi.readFile(path)
+		if err != nil {
+			hi.report = append(hi.report, fmt.Sprintf("%s : body file %s can't be read : %v", t.Name, path, err))
+			return t, true
+		}
+		t.In = data
+	} else {
+		t.In = []byte(hi.convertVariables(t.Name, content))
+	}
+	if t.CtIn == "" {
+		t.CtIn = "text/plain"
+		if json.Valid([]byte(madelyneVarRegexp.ReplaceAllString(string(t.In), "0"))) {
+			t.CtIn = "application/json"
+		}
+	}
+	return t, true
+}
+
+// ExportHttp writes the unit tests and scenarios of a group as a .http file,
+// `#var#` being rewritten as `{{var}}`.
+func ExportHttp(config testerconfig.Config, group string) ([]byte, error) {
+	g, ok := config.Groups[group]
+	if !ok {
+		return nil, fmt.Errorf("%w : %s", ErrUnknownGroup, group)
+	}
+
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "@baseUrl = %s\n", config.Url)
+	keys := make([]string, 0, len(g.Environment))
+	for k := range g.Environment {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(out, "@%s = %s\n", k, g.Environment[k])
+	}
+
+	for _, ut := range g.UnitTests {
+		writeHttpRequest(out, ut)
+	}
+	for _, name := range g.ScenarioOrder {
+		for _, ut := range g.Scenarios[name] {
+			writeHttpRequest(out, ut)
+		}
+	}
+	return []byte(out.String()), nil
+}
+
+func writeHttpRequest(out *strings.Builder, ut testerconfig.UnitTest) {
+	fmt.Fprintf(out, "\n### %s\n", ut.File)
+	if !ut.Replayable() {
+		fmt.Fprintf(out, "# %s action can't be replayed\n", ut.Action)
+		return
+	}
+	u := toTemplate(ut.Url)
+	if len(ut.Query) > 0 {
+		separator := "?"
+		if strings.Contains(u, "?") {
+			separator = "&"
+		}
+		u += separator + encodeTemplate(ut.Query)
+	}
+	fmt.Fprintf(out, "%s {{baseUrl}}%s\n", ut.Method(), u)
+
+	keys := make([]string, 0, len(ut.Headers))
+	for k := range ut.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	switch {
+	case ut.Form != nil:
+		fmt.Fprintf(out, "Content-Type: multipart/form-data; boundary=%s\n", httpBoundary)
+	case ut.FormUrlencoded != nil:
+		fmt.Fprintf(out, "Content-Type: application/x-www-form-urlencoded\n")
+	case ut.In != nil:
+		fmt.Fprintf(out, "Content-Type: %s\n", ut.CtIn)
+	}
+	for _, k := range keys {
+		if ut.Form != nil && strings.EqualFold(k, "Content-Type") {
+			continue
+		}
+		for _, v := range ut.Headers[k] {
+			fmt.Fprintf(out, "%s: %s\n", k, toTemplate(v))
+		}
+	}
+
+	if ut.Form != nil {
+		writeHttpForm(out, ut.Form)
+		return
+	}
+	if ut.FormUrlencoded != nil {
+		writeHttpFormUrlencoded(out, ut.FormUrlencoded)
+		return
+	}
+	if ut.In == nil {
+		return
+	}
+	if utf8.Valid(ut.In) {
+		fmt.Fprintf(out, "\n%s\n", toTemplate(strings.TrimRight(string(ut.In), "\n")))
+		return
+	}
+	fmt.Fprintf(out, "\n< %s\n", filepath.ToSlash(ut.InFile))
+}
+
+// writeHttpForm writes a multipart body, files being read by the client.
+func writeHttpForm(out *strings.Builder, form *testerconfig.Form) {
+	names := make([]string, 0, len(form.Fields))
+	for name := range form.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out.WriteString("\n")
+	for _, name := range names {
+		for _, v := range form.Fields[name] {
+			fmt.Fprintf(out, "--%s\nContent-Disposition: form-data; name=\"%s\"\n\n%s\n", httpBoundary, toTemplate(name), toTemplate(v))
+		}
+	}
+	for _, f := range form.Files {
+		fmt.Fprintf(out, "--%s\nContent-Disposition: form-data; name=\"%s\"; filename=\"%s\"\nContent-Type: %s\n\n< %s\n", httpBoundary, f.Name, f.Filename, f.ContentType, filepath.ToSlash(f.Path))
+	}
+	fmt.Fprintf(out, "--%s--\n", httpBoundary)
+}
+
+// writeHttpFormUrlencoded writes an url encoded body.
+func writeHttpFormUrlencoded(out *strings.Builder, values map[string][]string) {
+	fmt.Fprintf(out, "\n%s\n", encodeTemplate(values))
+}
+
+// encodeTemplate url encodes values sorted by key, like url.Values.Encode, but
+// variables are kept as templates so they are substituted by the client.
+func encodeTemplate(values map[string][]string) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, k := range keys {
+		for _, v := range values[k] {
+			pairs = append(pairs, escapeTemplate(k)+"="+escapeTemplate(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escapeTemplate url encodes s except its variables, which become templates.
+func escapeTemplate(s string) string {
+	out := ""
+	last := 0
+	for _, m := range madelyneVarRegexp.FindAllStringSubmatchIndex(s, -1) {
+		out += url.QueryEscape(s[last:m[0]]) + "{{" + s[m[2]:m[3]] + "}}"
+		last = m[1]
+	}
+	return out + url.QueryEscape(s[last:])
+}
+
+func toTemplate(s string) string {
+	return madelyneVarRegexp.ReplaceAllString(s, "{{$1}}")
+}
@@ -0,0 +1,180 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"reflect"
+	"testing"
+)
+
+const testHttpFile = `@host = http://localhost:3000
+@token = abc
+
+### All articles
+GET {{host}}/articles
+    ?limit=10
+    &offset=0
+Authorization: Bearer {{token}}
+
+###
+# @name create
+POST {{host}}/articles HTTP/1.1
+Content-Type: application/json
+
+{
+    "title": "{{title}}"
+}
+
+> {% client.global.set("id", response.body.id); %}
+
+### upload
+PUT http://localhost:3000/articles/1/attachment
+Content-Type: application/pdf
+X-Date: 12:00
+
+< ./article.pdf
+
+###
+HEAD /articles/{{$uuid}}
+
+###
+/health
+`
+
+func TestImportHttp(t *testing.T) {
+	files := map[string]string{"./article.pdf": "%PDF"}
+	readFile := func(path string) ([]byte, error) {
+		f, ok := files[path]
+		if !ok {
+			return nil, fmt.Errorf("file not found %s", path)
+		}
+		return []byte(f), nil
+	}
+
+	expected := Suite{
+		Url: "http://localhost:3000",
+		Groups: []Group{
+			{
+				Name:        "articles",
+				TestFile:    "articles.yml",
+				Environment: map[string]string{"host": "http://localhost:3000", "token": "abc"},
+				UnitTests: []Test{
+					{Name: "All articles", Action: "GET", Url: "/articles?limit=10&offset=0", Status: 200, Headers: map[string][]string{"Authorization": {"Bearer #token#"}}},
+					{Name: "create", Action: "POST", Url: "/articles", Status: 200, Headers: map[string][]string{}, CtIn: "application/json", In: []byte("{\n    \"title\": \"#title#\"\n}")},
+					{Name: "upload", Action: "PUT", Url: "/articles/1/attachment", Status: 200, Headers: map[string][]string{"X-Date": {"12:00"}}, CtIn: "application/pdf", In: []byte("%PDF")},
+					{Name: "request_4", Action: "HEAD", Url: "/articles/{{$uuid}}", Status: 200, Headers: map[string][]string{}},
+					{Name: "request_5", Action: "GET", Url: "/health", Status: 200, Headers: map[string][]string{}},
+				},
+				Scenarios: []Scenario{},
+			},
+		},
+	}
+	expectedReport := []string{
+		"create : response handler can't be converted : > {% client.global.set(\"id\", response.body.id); %}",
+		"request_4 : dynamic variable {{$uuid}} can't be converted",
+		"requests of .http files have no expected status, 200 is expected",
+	}
+
+	suite, report, err := ImportHttp("articles", []byte(testHttpFile), readFile)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if !reflect.DeepEqual(report, expectedReport) {
+		t.Fatalf("report failed \n exp %#v \n got %#v", expectedReport, report)
+	}
+	if !reflect.DeepEqual(suite, expected) {
+		t.Fatalf("failed \n exp %#v \n got %#v", expected, suite)
+	}
+}
+
+func TestExportHttp(t *testing.T) {
+	config := testerconfig.Config{
+		Url: "http://localhost:3000",
+		Groups: map[string]testerconfig.TestGroup{
+			"main": testerconfig.TestGroup{
+				GroupName:   "main",
+				Environment: map[string]string{"token": "abc", "id": "1"},
+				UnitTests: []testerconfig.UnitTest{
+					{File: "main/configs/tests.yml:GET", Action: "GET", Url: "/articles/#id#", Status: 200, CtIn: "application/json", Headers: map[string][]string{"Authorization": {"Bearer #token#"}, "Accept": {"*/*", "text/plain"}}},
+					{File: "main/configs/tests.yml:GET:1", Action: "GET", Url: "/articles?sort=date", Status: 200, CtIn: "application/json", Headers: map[string][]string{}, Query: map[string][]string{"q": {"a b", "#term#"}, "page": {"#page#"}}},
+					{File: "main/configs/tests.yml:POST", Action: "POST", Url: "/upload", Status: 200, CtIn: "application/json", Headers: map[string][]string{"Content-Type": {"multipart/form-data"}}, Form: &testerconfig.Form{
+						Fields: map[string][]string{"title": {"#title#"}},
+						Files:  []testerconfig.FormFile{{Name: "doc", Filename: "file.pdf", ContentType: "application/pdf", Path: "main/payloads/file.pdf"}},
+					}},
+					{File: "main/configs/tests.yml:POST:1", Action: "POST", Url: "/token", Status: 200, CtIn: "application/json", Headers: map[string][]string{}, FormUrlencoded: map[string][]string{"scope": {"read write"}, "username": {"#user#@test"}}},
+					{File: "main/configs/tests.yml:PUT", Action: "PUT", Url: "/articles/1/file", Status: 200, CtIn: "application/pdf", In: []byte{0xff, 0xfe}, InName: "file.pdf", InFile: "main/payloads/file.pdf", Headers: map[string][]string{}},
+				},
+				ScenarioOrder: []string{"main/configs/tests.yml:create"},
+				Scenarios: map[string][]testerconfig.UnitTest{
+					"main/configs/tests.yml:create": []testerconfig.UnitTest{
+						{File: "main/configs/tests.yml:create:POST:0", Action: "POST", Url: "/articles", Status: 201, CtIn: "application/json", In: []byte("{\"title\":\"#title#\"}\n"), Headers: map[string][]string{}},
+						{File: "main/configs/tests.yml:create:FILE:1", Action: "FILE", InName: "access.log"},
+					},
+				},
+			},
+		},
+	}
+	expected := `@baseUrl = http://localhost:3000
+@id = 1
+@token = abc
+
+### main/configs/tests.yml:GET
+GET {{baseUrl}}/articles/{{id}}
+Accept: */*
+Accept: text/plain
+Authorization: Bearer {{token}}
+
+### main/configs/tests.yml:GET:1
+GET {{baseUrl}}/articles?sort=date&page={{page}}&q=a+b&q={{term}}
+
+### main/configs/tests.yml:POST
+POST {{baseUrl}}/upload
+Content-Type: multipart/form-data; boundary=MadelyneBoundary
+
+--MadelyneBoundary
+Content-Disposition: form-data; name="title"
+
+{{title}}
+--MadelyneBoundary
+Content-Disposition: form-data; name="doc"; filename="file.pdf"
+Content-Type: application/pdf
+
+< main/payloads/file.pdf
+--MadelyneBoundary--
+
+### main/configs/tests.yml:POST:1
+POST {{baseUrl}}/token
+Content-Type: application/x-www-form-urlencoded
+
+scope=read+write&username={{user}}%40test
+
+### main/configs/tests.yml:PUT
+PUT {{baseUrl}}/articles/1/file
+Content-Type: application/pdf
+
+< main/payloads/file.pdf
+
+### main/configs/tests.yml:create:POST:0
+POST {{baseUrl}}/articles
+Content-Type: application/json
+
+{"title":"{{title}}"}
+
+### main/configs/tests.yml:create:FILE:1
+# FILE action can't be replayed
+`
+
+	result, err := ExportHttp(config, "main")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if string(result) != expected {
+		t.Fatalf("failed exp \n%s\n, got \n%s\n", expected, string(result))
+	}
+
+	_, err = ExportHttp(config, "unknown")
+	if !errors.Is(err, ErrUnknownGroup) {
+		t.Fatalf("failed got %v, exp %v", err, ErrUnknownGroup)
+	}
+}
@@ -0,0 +1,91 @@
+package converter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	templateVarRegexp  = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+	templateBaseRegexp = regexp.MustCompile(`^\{\{\s*([^{}]+?)\s*\}\}`)
+)
+
+// importer holds what is shared by the tools using `{{var}}` templates.
+type importer struct {
+	variables map[string]string
+	baseUrl   string
+	report    []string
+}
+
+func newImporter() importer {
+	return importer{
+		variables: map[string]string{},
+		report:    []string{},
+	}
+}
+
+func (im *importer) environment() map[string]string {
+	env := map[string]string{}
+	for k, v := range im.variables {
+		env[k] = v
+	}
+	return env
+}
+
+func (im *importer) convertUrl(path string, raw string) (string, bool) {
+	if raw == "" {
+		im.report = append(im.report, fmt.Sprintf("%s : request has no url", path))
+		return "", false
+	}
+	base := ""
+	if m := templateBaseRegexp.FindStringSubmatch(raw); m != nil {
+		base = im.variables[m[1]]
+		if base == "" {
+			base = m[0]
+		}
+		raw = raw[len(m[0]):]
+	} else if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		base = u.Scheme + "://" + u.Host
+		raw = strings.TrimPrefix(raw, base)
+	}
+	if base != "" {
+		if im.baseUrl == "" {
+			im.baseUrl = base
+		} else if im.baseUrl != base {
+			im.report = append(im.report, fmt.Sprintf("%s : base url %s differs from %s used in conf.yml", path, base, im.baseUrl))
+		}
+	}
+	if !strings.HasPrefix(raw, "/") {
+		raw = "/" + raw
+	}
+	return im.convertVariables(path, raw), true
+}
+
+func (im *importer) convertVariables(path string, s string) string {
+	return templateVarRegexp.ReplaceAllStringFunc(s, func(v string) string {
+		name := templateVarRegexp.FindStringSubmatch(v)[1]
+		if strings.HasPrefix(name, "$") {
+			im.report = append(im.report, fmt.Sprintf("%s : dynamic variable %s can't be converted", path, v))
+			return v
+		}
+		return "#" + name + "#"
+	})
+}
+
+func (im *importer) suiteUrl() string {
+	if im.baseUrl == "" {
+		im.report = append(im.report, "no base url found, conf.yml url set to http://localhost")
+		return "http://localhost"
+	}
+	return im.baseUrl
+}
+
+func (t *Test) addHeader(key string, value string) {
+	if strings.EqualFold(key, "Content-Type") {
+		t.CtIn = value
+		return
+	}
+	t.Headers[key] = append(t.Headers[key], value)
+}
@@ -0,0 +1,343 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPostman = fmt.Errorf("Invalid postman collection")
+)
+
+var (
+	postmanStatusRegexps = []*regexp.Regexp{
+		regexp.MustCompile(`pm\.response\.to\.have\.status\(\s*(\d{3})\s*\)`),
+		regexp.MustCompile(`pm\.expect\(\s*pm\.response\.code\s*\)\.to\.(?:be\.)?(?:eql|equal|equals)\(\s*(\d{3})\s*\)`),
+	}
+	postmanIgnoredLineRegexp = regexp.MustCompile(`^(//.*|pm\.test\(.*function\s*\(\)\s*\{|pm\.test\(.*=>\s*\{|\}\);?|\}\)|)$`)
+)
+
+type postmanCollection struct {
+	Info struct {
+		Name string `json:"name"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanVariable `json:"variable"`
+	Auth     *postmanAuth      `json:"auth"`
+	Event    []postmanEvent    `json:"event"`
+}
+
+type postmanVariable struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []postmanItem   `json:"item"`
+	Request *postmanRequest `json:"request"`
+	Event   []postmanEvent  `json:"event"`
+	Auth    *postmanAuth    `json:"auth"`
+}
+
+type postmanRequest struct {
+	Method string          `json:"method"`
+	Url    postmanUrl      `json:"url"`
+	Header []postmanHeader `json:"header"`
+	Body   *postmanBody    `json:"body"`
+	Auth   *postmanAuth    `json:"auth"`
+}
+
+func (r *postmanRequest) UnmarshalJSON(data []byte) error {
+	var raw string
+	if json.Unmarshal(data, &raw) == nil {
+		r.Method = "GET"
+		r.Url = postmanUrl(raw)
+		return nil
+	}
+	type plain postmanRequest
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+type postmanUrl string
+
+func (u *postmanUrl) UnmarshalJSON(data []byte) error {
+	var raw string
+	if json.Unmarshal(data, &raw) == nil {
+		*u = postmanUrl(raw)
+		return nil
+	}
+	object := struct {
+		Raw string `json:"raw"`
+	}{}
+	err := json.Unmarshal(data, &object)
+	if err != nil {
+		return err
+	}
+	*u = postmanUrl(object.Raw)
+	return nil
+}
+
+type postmanHeader struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+type postmanBody struct {
+	Mode    string `json:"mode"`
+	Raw     string `json:"raw"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Bearer []postmanVariable `json:"bearer"`
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec postmanScript `json:"exec"`
+	} `json:"script"`
+}
+
+type postmanScript []string
+
+func (s *postmanScript) UnmarshalJSON(data []byte) error {
+	var raw string
+	if json.Unmarshal(data, &raw) == nil {
+		*s = strings.Split(raw, "\n")
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(s))
+}
+
+type postmanImporter struct {
+	importer
+	auth *postmanAuth
+}
+
+// ImportPostman converts a postman collection (v2.0 or v2.1) into a suite.
+// Everything that can't be converted is listed in the returned report.
+func ImportPostman(data []byte) (Suite, []string, error) {
+	c := postmanCollection{}
+	err := json.Unmarshal(data, &c)
+	if err != nil {
+		return Suite{}, nil, fmt.Errorf("%w : %v", ErrInvalidPostman, err)
+	}
+
+	pi := &postmanImporter{
+		importer: newImporter(),
+		auth:     c.Auth,
+	}
+	for _, v := range c.Variable {
+		pi.variables[v.Key] = fmt.Sprintf("%v", v.Value)
+	}
+	pi.reportScripts(c.Info.Name, c.Event)
+
+	suite := Suite{Groups: []Group{}}
+	rootName := Slug(c.Info.Name)
+	root := pi.newGroup(rootName)
+	for _, item := range c.Item {
+		if item.Request != nil {
+			root.UnitTests = append(root.UnitTests, pi.unitTests(item.Name, item)...)
+			continue
+		}
+		g := pi.newGroup(Slug(item.Name))
+		pi.reportScripts(item.Name, item.Event)
+		for _, sub := range item.Item {
+			path := item.Name + "/" + sub.Name
+			if sub.Request != nil {
+				g.UnitTests = append(g.UnitTests, pi.unitTests(path, sub)...)
+				continue
+			}
+			g.Scenarios = append(g.Scenarios, Scenario{
+				Name:  sub.Name,
+				Steps: pi.steps(path, sub),
+			})
+		}
+		suite.Groups = append(suite.Groups, g)
+	}
+	if len(root.UnitTests) > 0 {
+		suite.Groups = append([]Group{root}, suite.Groups...)
+	}
+
+	suite.Url = pi.suiteUrl()
+	return suite, pi.report, nil
+}
+
+func (pi *postmanImporter) newGroup(name string) Group {
+	return Group{
+		Name:        name,
+		TestFile:    "postman.yml",
+		Environment: pi.environment(),
+		UnitTests:   []Test{},
+		Scenarios:   []Scenario{},
+	}
+}
+
+func (pi *postmanImporter) unitTests(path string, item postmanItem) []Test {
+	t, ok := pi.convert(path, item)
+	if !ok {
+		return []Test{}
+	}
+	return []Test{t}
+}
+
+func (pi *postmanImporter) steps(path string, folder postmanItem) []Test {
+	pi.reportScripts(path, folder.Event)
+	steps := []Test{}
+	for _, item := range folder.Item {
+		itemPath := path + "/" + item.Name
+		if item.Request == nil {
+			steps = append(steps, pi.steps(itemPath, item)...)
+			continue
+		}
+		t, ok := pi.convert(itemPath, item)
+		if ok {
+			steps = append(steps, t)
+		}
+	}
+	return steps
+}
+
+func (pi *postmanImporter) convert(path string, item postmanItem) (Test, bool) {
+	r := item.Request
+	t := Test{
+		Name:    item.Name,
+		Action:  strings.ToUpper(r.Method),
+		Headers: map[string][]string{},
+	}
+	if t.Action == "" {
+		t.Action = "GET"
+	}
+
+	u, ok := pi.convertUrl(path, string(r.Url))
+	if !ok {
+		return Test{}, false
+	}
+	t.Url = u
+
+	for _, h := range r.Header {
+		if h.Disabled {
+			continue
+		}
+		value := pi.convertVariables(path, h.Value)
+		t.addHeader(h.Key, value)
+	}
+
+	auth := r.Auth
+	if auth == nil {
+		auth = item.Auth
+	}
+	if auth == nil {
+		auth = pi.auth
+	}
+	pi.convertAuth(path, auth, &t)
+
+	if r.Body != nil {
+		pi.convertBody(path, r.Body, &t)
+	}
+
+	t.Status = pi.convertStatus(path, item.Event)
+	return t, true
+}
+
+func (pi *postmanImporter) convertAuth(path string, auth *postmanAuth, t *Test) {
+	if auth == nil || auth.Type == "" || auth.Type == "noauth" {
+		return
+	}
+	if auth.Type != "bearer" {
+		pi.report = append(pi.report, fmt.Sprintf("%s : auth of type %s can't be converted", path, auth.Type))
+		return
+	}
+	for _, v := range auth.Bearer {
+		if v.Key == "token" {
+			t.Headers["Authorization"] = []string{"Bearer " + pi.convertVariables(path, fmt.Sprintf("%v", v.Value))}
+		}
+	}
+}
+
+func (pi *postmanImporter) convertBody(path string, body *postmanBody, t *Test) {
+	if body.Mode != "raw" {
+		pi.report = append(pi.report, fmt.Sprintf("%s : body of mode %s can't be converted", path, body.Mode))
+		return
+	}
+	if body.Raw == "" {
+		return
+	}
+	if t.CtIn == "" {
+		switch body.Options.Raw.Language {
+		case "json":
+			t.CtIn = "application/json"
+		case "xml":
+			t.CtIn = "application/xml"
+		case "html":
+			t.CtIn = "text/html"
+		case "javascript":
+			t.CtIn = "application/javascript"
+		case "text":
+			t.CtIn = "text/plain"
+		default:
+			t.CtIn = "text/plain"
+			if json.Valid([]byte(templateVarRegexp.ReplaceAllString(body.Raw, "0"))) {
+				t.CtIn = "application/json"
+			}
+		}
+	}
+	t.In = []byte(pi.convertVariables(path, body.Raw))
+}
+
+func (pi *postmanImporter) convertStatus(path string, events []postmanEvent) int {
+	status := 0
+	for _, e := range events {
+		if e.Listen != "test" {
+			if e.Listen == "prerequest" && len(strings.TrimSpace(strings.Join(e.Script.Exec, ""))) > 0 {
+				pi.report = append(pi.report, fmt.Sprintf("%s : pre-request script can't be converted", path))
+			}
+			continue
+		}
+		for _, line := range e.Script.Exec {
+			line = strings.TrimSpace(line)
+			found := false
+			for _, r := range postmanStatusRegexps {
+				m := r.FindStringSubmatch(line)
+				if m == nil {
+					continue
+				}
+				s, _ := strconv.Atoi(m[1])
+				if status != 0 && status != s {
+					pi.report = append(pi.report, fmt.Sprintf("%s : several status expected, only %d is kept", path, status))
+				} else {
+					status = s
+				}
+				found = true
+				break
+			}
+			if !found && !postmanIgnoredLineRegexp.MatchString(line) {
+				pi.report = append(pi.report, fmt.Sprintf("%s : test line can't be converted : %s", path, line))
+			}
+		}
+	}
+	if status == 0 {
+		pi.report = append(pi.report, fmt.Sprintf("%s : no status check found, 200 is expected", path))
+		status = 200
+	}
+	return status
+}
+
+func (pi *postmanImporter) reportScripts(path string, events []postmanEvent) {
+	for _, e := range events {
+		if len(strings.TrimSpace(strings.Join(e.Script.Exec, ""))) > 0 {
+			pi.report = append(pi.report, fmt.Sprintf("%s : %s script can't be converted", path, e.Listen))
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package converter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const testCollection = `{
+	"info": { "name": "Blog API" },
+	"variable": [ { "key": "baseUrl", "value": "http://localhost:3000" }, { "key": "token", "value": "abc" } ],
+	"auth": { "type": "bearer", "bearer": [ { "key": "token", "value": "{{token}}" } ] },
+	"item": [
+		{
+			"name": "ping",
+			"request": { "method": "GET", "url": "{{baseUrl}}/ping", "auth": { "type": "noauth" } },
+			"event": [ { "listen": "test", "script": { "exec": [
+				"pm.test(\"Status code is 200\", function () {",
+				"    pm.response.to.have.status(200);",
+				"});"
+			] } } ]
+		},
+		{
+			"name": "Articles",
+			"item": [
+				{
+					"name": "All articles",
+					"request": {
+						"method": "GET",
+						"url": { "raw": "{{baseUrl}}/articles?limit=10" },
+						"header": [ { "key": "X-Debug", "value": "1", "disabled": true }, { "key": "X-Date", "value": "12:00" } ]
+					},
+					"event": [ { "listen": "test", "script": { "exec": "pm.expect(pm.response.code).to.eql(200);\npm.expect(pm.response.json().length).to.eql(10);" } } ]
+				},
+				{
+					"name": "Options",
+					"request": { "method": "OPTIONS", "url": "{{baseUrl}}/articles" }
+				},
+				{
+					"name": "Create and delete",
+					"item": [
+						{
+							"name": "create",
+							"request": {
+								"method": "POST",
+								"url": "{{baseUrl}}/articles",
+								"body": { "mode": "raw", "raw": "{\"title\":\"{{title}}\",\"id\":{{$randomInt}}}", "options": { "raw": { "language": "json" } } }
+							},
+							"event": [ { "listen": "test", "script": { "exec": [ "pm.response.to.have.status(201);" ] } } ]
+						},
+						{
+							"name": "delete",
+							"request": { "method": "DELETE", "url": "http://other:4000/articles/{{id}}" },
+							"event": [ { "listen": "prerequest", "script": { "exec": [ "pm.variables.set('id', 1);" ] } }, { "listen": "test", "script": { "exec": [ "pm.response.to.have.status(204);" ] } } ]
+						}
+					]
+				},
+				{
+					"name": "upload",
+					"item": [
+						{
+							"name": "send",
+							"request": { "method": "POST", "url": "{{baseUrl}}/upload", "body": { "mode": "formdata" } },
+							"event": [ { "listen": "test", "script": { "exec": [ "pm.response.to.have.status(201);" ] } } ]
+						}
+					]
+				}
+			]
+		}
+	]
+}`
+
+func TestImportPostman(t *testing.T) {
+	env := map[string]string{"baseUrl": "http://localhost:3000", "token": "abc"}
+	expected := Suite{
+		Url: "http://localhost:3000",
+		Groups: []Group{
+			{
+				Name:        "Blog_API",
+				TestFile:    "postman.yml",
+				Environment: env,
+				UnitTests: []Test{
+					{Name: "ping", Action: "GET", Url: "/ping", Status: 200, Headers: map[string][]string{}},
+				},
+				Scenarios: []Scenario{},
+			},
+			{
+				Name:        "Articles",
+				TestFile:    "postman.yml",
+				Environment: env,
+				UnitTests: []Test{
+					{Name: "All articles", Action: "GET", Url: "/articles?limit=10", Status: 200, Headers: map[string][]string{"Authorization": {"Bearer #token#"}, "X-Date": {"12:00"}}},
+					{Name: "Options", Action: "OPTIONS", Url: "/articles", Status: 200, Headers: map[string][]string{"Authorization": {"Bearer #token#"}}},
+				},
+				Scenarios: []Scenario{
+					{
+						Name: "Create and delete",
+						Steps: []Test{
+							{
+								Name:    "create",
+								Action:  "POST",
+								Url:     "/articles",
+								Status:  201,
+								Headers: map[string][]string{"Authorization": {"Bearer #token#"}},
+								CtIn:    "application/json",
+								In:      []byte(`{"title":"#title#","id":{{$randomInt}}}`),
+							},
+							{Name: "delete", Action: "DELETE", Url: "/articles/#id#", Status: 204, Headers: map[string][]string{"Authorization": {"Bearer #token#"}}},
+						},
+					},
+					{
+						Name: "upload",
+						Steps: []Test{
+							{Name: "send", Action: "POST", Url: "/upload", Status: 201, Headers: map[string][]string{"Authorization": {"Bearer #token#"}}},
+						},
+					},
+				},
+			},
+		},
+	}
+	expectedReport := []string{
+		"Articles/All articles : test line can't be converted : pm.expect(pm.response.json().length).to.eql(10);",
+		"Articles/Options : no status check found, 200 is expected",
+		"Articles/Create and delete/create : dynamic variable {{$randomInt}} can't be converted",
+		"Articles/Create and delete/delete : base url http://other:4000 differs from http://localhost:3000 used in conf.yml",
+		"Articles/Create and delete/delete : pre-request script can't be converted",
+		"Articles/upload/send : body of mode formdata can't be converted",
+	}
+
+	suite, report, err := ImportPostman([]byte(testCollection))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if !reflect.DeepEqual(report, expectedReport) {
+		t.Fatalf("report failed \n exp %#v \n got %#v", expectedReport, report)
+	}
+	if !reflect.DeepEqual(suite, expected) {
+		t.Fatalf("failed \n exp %#v \n got %#v", expected, suite)
+	}
+}
+
+func TestImportPostmanInvalid(t *testing.T) {
+	_, _, err := ImportPostman([]byte(`[`))
+	if !errors.Is(err, ErrInvalidPostman) {
+		t.Fatalf("failed got %v, exp %v", err, ErrInvalidPostman)
+	}
+}
@@ -0,0 +1,243 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DefaultDeniedHeaders are the headers whose values are never written by the
+// recorder.
+var DefaultDeniedHeaders = []string{"Authorization", "Proxy-Authorization", "X-Api-Key"}
+
+// ignoredHeaders are set by the browser or the transport, or replayed by the
+// cookie jar of the tester, so they aren't recorded.
+var ignoredHeaders = map[string]bool{
+	"Accept":                    true,
+	"Accept-Encoding":           true,
+	"Accept-Language":           true,
+	"Cache-Control":             true,
+	"Connection":                true,
+	"Content-Length":            true,
+	"Cookie":                    true,
+	"Dnt":                       true,
+	"Host":                      true,
+	"If-Modified-Since":         true,
+	"If-None-Match":             true,
+	"Keep-Alive":                true,
+	"Origin":                    true,
+	"Pragma":                    true,
+	"Priority":                  true,
+	"Referer":                   true,
+	"Te":                        true,
+	"Upgrade-Insecure-Requests": true,
+	"User-Agent":                true,
+	"X-Forwarded-For":           true,
+	"X-Forwarded-Host":          true,
+	"X-Forwarded-Proto":         true,
+}
+
+// Recorder is a reverse proxy to a target recording each exchange as a
+// scenario step. When allow is not empty, only its headers are recorded. The
+// denied headers are recorded as `#variable#`, left empty in the environment,
+// so their values are never written.
+type Recorder struct {
+	target *url.URL
+	proxy  *httputil.ReverseProxy
+	allow  map[string]bool
+	deny   map[string]bool
+	// Log, when set, receives a line for each exchange recorded.
+	Log io.Writer
+
+	mutex sync.Mutex
+	steps []Test
+	env   map[string]string
+}
+
+func NewRecorder(target *url.URL, allow []string, deny []string) *Recorder {
+	r := &Recorder{
+		target: target,
+		allow:  map[string]bool{},
+		deny:   map[string]bool{},
+		steps:  []Test{},
+		env:    map[string]string{},
+	}
+	for _, h := range allow {
+		r.allow[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, h := range deny {
+		r.deny[http.CanonicalHeaderKey(h)] = true
+	}
+
+	r.proxy = httputil.NewSingleHostReverseProxy(target)
+	director := r.proxy.Director
+	r.proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = target.Host
+		// the responses are recorded uncompressed
+		req.Header.Del("Accept-Encoding")
+	}
+	r.proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		if cw, ok := w.(*captureWriter); ok {
+			cw.failed = true
+		}
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprintf(w, "cannot reach %s : %v", target, err)
+	}
+	return r
+}
+
+// Steps gives the exchanges recorded, in the order they ended.
+func (r *Recorder) Steps() []Test {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Test{}, r.steps...)
+}
+
+// Suite gives a suite of one group, with cookies, whose scenario holds the
+// steps recorded.
+func (r *Recorder) Suite(group string, scenario string) Suite {
+	r.mutex.Lock()
+	env := map[string]string{}
+	for k, v := range r.env {
+		env[k] = v
+	}
+	r.mutex.Unlock()
+	return Suite{
+		Url: strings.TrimSuffix(r.target.String(), "/"),
+		Groups: []Group{
+			{
+				Name:        group,
+				TestFile:    group + ".yml",
+				Environment: env,
+				Scenarios:   []Scenario{{Name: scenario, Steps: r.Steps()}},
+				// the cookies aren't recorded, they are replayed by the jar
+				Cookies: true,
+			},
+		},
+	}
+}
+
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	cw := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+	r.proxy.ServeHTTP(cw, req)
+	// preflights are sent by the browser, websockets can't be recorded
+	if cw.failed || req.Method == http.MethodOptions || cw.status == http.StatusSwitchingProtocols {
+		return
+	}
+
+	t := Test{
+		Name:    req.Method + " " + req.URL.Path,
+		Action:  req.Method,
+		Url:     req.URL.RequestURI(),
+		Status:  cw.status,
+		Headers: map[string][]string{},
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for name, values := range req.Header {
+		r.recordHeader(&t, name, values)
+	}
+	if len(body) > 0 {
+		t.In = body
+		if mediaType(t.CtIn) == "application/json" {
+			t.CtIn = "application/json"
+		}
+	}
+	if cw.body.Len() > 0 && req.Method != http.MethodHead && cw.status != http.StatusNoContent && cw.status != http.StatusNotModified {
+		t.CtOut = mediaType(cw.Header().Get("Content-Type"))
+		t.Out = cw.body.Bytes()
+		if t.CtOut == "" || t.CtOut == "application/json" {
+			t.CtOut = "application/json"
+			indented := &bytes.Buffer{}
+			if json.Indent(indented, t.Out, "", "    ") == nil {
+				t.Out = indented.Bytes()
+			}
+		}
+	}
+	r.steps = append(r.steps, t)
+	if r.Log != nil {
+		fmt.Fprintf(r.Log, "%s %s -> %d\n", t.Action, t.Url, t.Status)
+	}
+}
+
+func (r *Recorder) recordHeader(t *Test, name string, values []string) {
+	name = http.CanonicalHeaderKey(name)
+	if strings.EqualFold(name, "Content-Type") {
+		t.addHeader(name, values[0])
+		return
+	}
+	if ignoredHeaders[name] || strings.HasPrefix(name, "Sec-") {
+		return
+	}
+	if len(r.allow) > 0 && !r.allow[name] {
+		return
+	}
+	if r.deny[name] {
+		variable := strings.ToLower(strings.Replace(Slug(name), "-", "_", -1))
+		r.env[variable] = ""
+		t.Headers[name] = []string{"#" + variable + "#"}
+		return
+	}
+	for _, v := range values {
+		t.addHeader(name, v)
+	}
+}
+
+func mediaType(contentType string) string {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return t
+}
+
+// captureWriter keeps a copy of the response written by the proxy.
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+	failed bool
+}
+
+func (cw *captureWriter) WriteHeader(status int) {
+	cw.status = status
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *captureWriter) Write(data []byte) (int, error) {
+	cw.body.Write(data)
+	return cw.ResponseWriter.Write(data)
+}
+
+func (cw *captureWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *captureWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("connection can't be hijacked")
+	}
+	cw.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
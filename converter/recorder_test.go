@@ -0,0 +1,132 @@
+package converter
+
+import (
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /articles":
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":1,"in":` + string(body) + `}`))
+		case "GET /articles":
+			w.Header().Set("Content-Type", "text/csv")
+			w.Write([]byte("id\n1\n"))
+		case "DELETE /articles/1":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer target.Close()
+	targetUrl, _ := url.Parse(target.URL)
+
+	recorder := NewRecorder(targetUrl, nil, DefaultDeniedHeaders)
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	send := func(method string, path string, body string, headers map[string]string) {
+		req, _ := http.NewRequest(method, proxy.URL+path, strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed %v", err)
+		}
+		resp.Body.Close()
+	}
+	send("POST", "/articles", `{"a":1}`, map[string]string{"Content-Type": "application/json", "Authorization": "Bearer secret", "X-Tenant": "acme", "Cookie": "s=1"})
+	send("OPTIONS", "/articles", "", nil)
+	send("GET", "/articles?page=2", "", map[string]string{"Sec-Fetch-Mode": "cors"})
+	send("DELETE", "/articles/1", "", nil)
+
+	expected := []Test{
+		{
+			Name:    "POST /articles",
+			Action:  "POST",
+			Url:     "/articles",
+			Status:  201,
+			Headers: map[string][]string{"Authorization": {"#authorization#"}, "X-Tenant": {"acme"}},
+			CtIn:    "application/json",
+			In:      []byte(`{"a":1}`),
+			CtOut:   "application/json",
+			Out:     []byte("{\n    \"id\": 1,\n    \"in\": {\n        \"a\": 1\n    }\n}"),
+		},
+		{Name: "GET /articles", Action: "GET", Url: "/articles?page=2", Status: 200, Headers: map[string][]string{}, CtOut: "text/csv", Out: []byte("id\n1\n")},
+		{Name: "DELETE /articles/1", Action: "DELETE", Url: "/articles/1", Status: 204, Headers: map[string][]string{}},
+	}
+	steps := recorder.Steps()
+	if !reflect.DeepEqual(steps, expected) {
+		t.Fatalf("failed exp \n%#v\n, got \n%#v\n", expected, steps)
+	}
+
+	dir := t.TempDir()
+	suite := recorder.Suite("recorded", "session")
+	err := suite.Write(dir)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	for _, file := range []string{"env.json", "configs/recorded.yml", "payloads/scenarios/session/POST_articles.json", "responses/scenarios/session/POST_articles.json", "responses/scenarios/session/GET_articles"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, "recorded", file))
+		if err != nil {
+			t.Fatalf("failed %v", err)
+		}
+		if strings.Contains(string(data), "secret") {
+			t.Fatalf("secret written in %s", file)
+		}
+	}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+	config, err := testerconfig.New().Load("conf.yml")
+	if err != nil {
+		t.Fatalf("written suite can't be loaded %v", err)
+	}
+	if config.Url != target.URL {
+		t.Fatalf("failed exp %s got %s", target.URL, config.Url)
+	}
+	group := config.Groups["recorded"]
+	if !group.Cookies {
+		t.Fatalf("failed the recorded group needs cookies")
+	}
+	if group.Environment["authorization"] != "" {
+		t.Fatalf("failed env %v", group.Environment)
+	}
+	loaded := group.Scenarios["recorded/configs/recorded.yml:session"]
+	if len(loaded) != 3 || loaded[1].CtOut != "text/csv" || string(loaded[1].Out) != "id\n1\n" || string(loaded[0].Out) != string(expected[0].Out) {
+		t.Fatalf("failed scenario %#v", loaded)
+	}
+}
+
+func TestRecorderAllow(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+	targetUrl, _ := url.Parse(target.URL)
+
+	recorder := NewRecorder(targetUrl, []string{"x-tenant", "X-Api-Key"}, []string{"x-api-key"})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("X-Api-Key", "secret")
+	req.Header.Set("X-Other", "1")
+	recorder.ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := map[string][]string{"X-Tenant": {"acme"}, "X-Api-Key": {"#x_api_key#"}}
+	steps := recorder.Steps()
+	if len(steps) != 1 || !reflect.DeepEqual(steps[0].Headers, expected) {
+		t.Fatalf("failed exp %v got %#v", expected, steps)
+	}
+}
@@ -0,0 +1,261 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrFileExists = fmt.Errorf("File already exists")
+)
+
+type Suite struct {
+	Url    string
+	Groups []Group
+}
+
+type Group struct {
+	Name        string
+	TestFile    string
+	Environment map[string]string
+	UnitTests   []Test
+	Scenarios   []Scenario
+	// Cookies gives a cookie jar to each scenario of the group.
+	Cookies bool
+}
+
+type Scenario struct {
+	Name  string
+	Steps []Test
+}
+
+type Test struct {
+	Name    string
+	Action  string
+	Url     string
+	Status  int
+	Headers map[string][]string
+	CtIn    string
+	In      []byte
+	CtOut   string
+	Out     []byte
+}
+
+var slugRegexp = regexp.MustCompile(`[^a-zA-Z0-9_\-]+`)
+
+func Slug(name string) string {
+	s := strings.Trim(slugRegexp.ReplaceAllString(name, "_"), "_")
+	if s == "" {
+		return "unnamed"
+	}
+	return s
+}
+
+// Write creates the conf.yml, the test files, the env files, the payloads and
+// the responses of the suite in dir. Existing files are never overwritten,
+// nothing is written when one of them exists.
+func (s Suite) Write(dir string) error {
+	files, err := s.files(dir)
+	if err != nil {
+		return err
+	}
+	err = checkFiles(files)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		err = writeFile(f.name, f.data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check returns ErrFileExists when a file of the suite already exists in dir.
+func (s Suite) Check(dir string) error {
+	files, err := s.files(dir)
+	if err != nil {
+		return err
+	}
+	return checkFiles(files)
+}
+
+type file struct {
+	name string
+	data []byte
+}
+
+func (s Suite) files(dir string) ([]file, error) {
+	files := []file{}
+	conf := &strings.Builder{}
+	fmt.Fprintf(conf, "url: %s\ngroups:\n", quote(s.Url))
+	for _, g := range s.Groups {
+		fmt.Fprintf(conf, "  %s:\n", g.Name)
+		if g.Cookies {
+			fmt.Fprintf(conf, "    cookies: true\n")
+		}
+		if len(g.Environment) > 0 {
+			fmt.Fprintf(conf, "    environment: env.json\n")
+			env, err := json.MarshalIndent(g.Environment, "", "    ")
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file{filepath.Join(dir, g.Name, "env.json"), env})
+		}
+		fmt.Fprintf(conf, "    tests:\n      - %s\n", g.TestFile)
+		files = append(files, g.files(dir)...)
+	}
+	return append(files, file{filepath.Join(dir, "conf.yml"), []byte(conf.String())}), nil
+}
+
+func checkFiles(files []file) error {
+	seen := map[string]bool{}
+	for _, f := range files {
+		name := filepath.Clean(f.name)
+		if _, err := os.Stat(name); err == nil || seen[name] {
+			return fmt.Errorf("%w : %s", ErrFileExists, name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+func (g Group) files(dir string) []file {
+	files := []file{}
+	payloads := map[string]bool{}
+	out := &strings.Builder{}
+
+	if len(g.UnitTests) > 0 {
+		fmt.Fprintf(out, "unit_tests:\n")
+		byAction := map[string][]Test{}
+		actions := []string{}
+		for _, t := range g.UnitTests {
+			if _, ok := byAction[t.Action]; !ok {
+				actions = append(actions, t.Action)
+			}
+			byAction[t.Action] = append(byAction[t.Action], t)
+		}
+		for _, action := range actions {
+			fmt.Fprintf(out, "  %s:\n", action)
+			for _, t := range byAction[action] {
+				line, testFiles := g.writeTest(dir, "unitTests", t, false, payloads)
+				files = append(files, testFiles...)
+				fmt.Fprintf(out, "    - %s\n", line)
+			}
+		}
+	}
+
+	if len(g.Scenarios) > 0 {
+		fmt.Fprintf(out, "scenario:\n")
+		for _, s := range g.Scenarios {
+			fmt.Fprintf(out, "  %s:\n", Slug(s.Name))
+			for _, t := range s.Steps {
+				line, testFiles := g.writeTest(dir, "scenarios/"+Slug(s.Name), t, true, payloads)
+				files = append(files, testFiles...)
+				fmt.Fprintf(out, "    - %s\n", line)
+			}
+		}
+	}
+
+	return append(files, file{filepath.Join(dir, g.Name, "configs", g.TestFile), []byte(out.String())})
+}
+
+// writeTest gives the line of the test and its payload and response files.
+func (g Group) writeTest(dir string, folder string, t Test, withAction bool, payloads map[string]bool) (string, []file) {
+	files := []file{}
+	fields := []string{}
+	if withAction {
+		fields = append(fields, "action: "+quote(t.Action))
+	}
+	fields = append(fields, "url: "+quote(t.Url))
+	status := t.Status
+	if status == 0 {
+		status = 200
+	}
+	fields = append(fields, fmt.Sprintf("status: %d", status))
+
+	if len(t.Headers) > 0 {
+		keys := make([]string, 0, len(t.Headers))
+		for k := range t.Headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		headers := make([]string, 0, len(keys))
+		for _, k := range keys {
+			values := t.Headers[k]
+			if len(values) == 1 {
+				headers = append(headers, quote(k)+": "+quote(values[0]))
+				continue
+			}
+			quoted := make([]string, 0, len(values))
+			for _, v := range values {
+				quoted = append(quoted, quote(v))
+			}
+			headers = append(headers, quote(k)+": [ "+strings.Join(quoted, ", ")+" ]")
+		}
+		fields = append(fields, "headers: { "+strings.Join(headers, ", ")+" }")
+	}
+
+	name := ""
+	if t.In != nil || t.Out != nil {
+		name = folder + "/" + Slug(t.Name)
+		for i := 2; payloads[name]; i++ {
+			name = fmt.Sprintf("%s/%s_%d", folder, Slug(t.Name), i)
+		}
+		payloads[name] = true
+	}
+
+	if t.In != nil {
+		filename := name
+		if t.CtIn == "" || t.CtIn == "application/json" {
+			filename += ".json"
+		} else {
+			fields = append(fields, "ct_in: "+quote(t.CtIn))
+		}
+		files = append(files, file{filepath.Join(dir, g.Name, "payloads", filename), t.In})
+		fields = append(fields, "in: "+quote(name))
+	}
+
+	if t.Out != nil {
+		ctOut := t.CtOut
+		if ctOut == "" {
+			ctOut = "application/json"
+		}
+		filename := name
+		if ctOut == "application/json" {
+			filename += ".json"
+		}
+		files = append(files, file{filepath.Join(dir, g.Name, "responses", filename), t.Out})
+		fields = append(fields, "ct_out: "+quote(ctOut), "out: "+quote(name))
+	}
+
+	return "{ " + strings.Join(fields, ", ") + " }", files
+}
+
+func quote(s string) string {
+	b := &bytes.Buffer{}
+	e := json.NewEncoder(b)
+	e.SetEscapeHTML(false)
+	e.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func writeFile(filename string, data []byte) error {
+	filename = filepath.Clean(filename)
+	if _, err := os.Stat(filename); err == nil {
+		return fmt.Errorf("%w : %s", ErrFileExists, filename)
+	}
+	err := os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
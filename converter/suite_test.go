@@ -0,0 +1,129 @@
+package converter
+
+import (
+	"errors"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	suite := Suite{
+		Url: "http://localhost:3000",
+		Groups: []Group{
+			{
+				Name:        "main",
+				TestFile:    "imported.yml",
+				Environment: map[string]string{"token": "abc"},
+				UnitTests: []Test{
+					{Name: "all", Action: "GET", Url: "/articles", Status: 200, Headers: map[string][]string{"Authorization": {"Bearer #token#"}, "X-Test": {"1", "12:00"}}},
+					{Name: "create", Action: "POST", Url: "/articles", Status: 201, In: []byte(`{"a":1}`)},
+					{Name: "create", Action: "POST", Url: "/articles", Status: 201, CtIn: "text/plain", In: []byte(`a`)},
+				},
+				Scenarios: []Scenario{
+					{
+						Name: "create then delete",
+						Steps: []Test{
+							{Name: "create", Action: "POST", Url: "/articles", Status: 201, In: []byte(`{"a":2}`)},
+							{Name: "delete", Action: "DELETE", Url: "/articles/1", Status: 204},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dir := t.TempDir()
+	err := suite.Write(dir)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	expectedTests := `unit_tests:
+  GET:
+    - { url: "/articles", status: 200, headers: { "Authorization": "Bearer #token#", "X-Test": [ "1", "12:00" ] } }
+  POST:
+    - { url: "/articles", status: 201, in: "unitTests/create" }
+    - { url: "/articles", status: 201, ct_in: "text/plain", in: "unitTests/create_2" }
+scenario:
+  create_then_delete:
+    - { action: "POST", url: "/articles", status: 201, in: "scenarios/create_then_delete/create" }
+    - { action: "DELETE", url: "/articles/1", status: 204 }
+`
+	data, err := ioutil.ReadFile(filepath.Join(dir, "main", "configs", "imported.yml"))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if string(data) != expectedTests {
+		t.Fatalf("failed exp \n%s\n, got \n%s\n", expectedTests, string(data))
+	}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+	config, err := testerconfig.New().Load("conf.yml")
+	if err != nil {
+		t.Fatalf("written suite can't be loaded %v", err)
+	}
+	if config.Url != suite.Url {
+		t.Fatalf("failed exp %s got %s", suite.Url, config.Url)
+	}
+	group := config.Groups["main"]
+	if !reflect.DeepEqual(group.Environment, suite.Groups[0].Environment) {
+		t.Fatalf("failed exp %v got %v", suite.Groups[0].Environment, group.Environment)
+	}
+	if len(group.UnitTests) != 3 || string(group.UnitTests[2].In) != "a" {
+		t.Fatalf("failed unit tests %#v", group.UnitTests)
+	}
+	if !reflect.DeepEqual(group.UnitTests[0].Headers, suite.Groups[0].UnitTests[0].Headers) {
+		t.Fatalf("failed exp %v got %v", suite.Groups[0].UnitTests[0].Headers, group.UnitTests[0].Headers)
+	}
+	if len(group.Scenarios) != 1 {
+		t.Fatalf("failed scenarios %#v", group.Scenarios)
+	}
+
+	err = suite.Write(dir)
+	if !errors.Is(err, ErrFileExists) {
+		t.Fatalf("failed got %v, exp %v", err, ErrFileExists)
+	}
+
+	// nothing is written when a file exists
+	other := t.TempDir()
+	err = ioutil.WriteFile(filepath.Join(other, "conf.yml"), []byte("url: a"), 0644)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	err = suite.Check(other)
+	if !errors.Is(err, ErrFileExists) {
+		t.Fatalf("failed got %v, exp %v", err, ErrFileExists)
+	}
+	err = suite.Write(other)
+	if !errors.Is(err, ErrFileExists) {
+		t.Fatalf("failed got %v, exp %v", err, ErrFileExists)
+	}
+	if _, err := os.Stat(filepath.Join(other, "main")); !os.IsNotExist(err) {
+		t.Fatalf("failed group files written %v", err)
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Blog API", "Blog_API"},
+		{"  /a//b ", "a_b"},
+		{"ok-name_1", "ok-name_1"},
+		{"", "unnamed"},
+	}
+
+	for i, tt := range tests {
+		result := Slug(tt.input)
+		if result != tt.expected {
+			t.Fatalf("%d : failed got %s exp %s", i, result, tt.expected)
+		}
+	}
+}
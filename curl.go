@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerclient"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"github.com/madelyne-io/madelyne/tester/unittester"
+	"regexp"
+	"strings"
+)
+
+const curlUsage = "usage: madelyne curl <test> [--conf conf.yml] [--set name=value ...]"
+
+var unresolvedVarRegexp = regexp.MustCompile(`#([A-Za-z0-9_.\-]+)#`)
+
+type setFlag map[string]string
+
+func (s setFlag) String() string { return "" }
+func (s setFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("%s must be formated as name=value", value)
+	}
+	s[parts[0]] = parts[1]
+	return nil
+}
+
+func runCurl(args []string) int {
+	flags := flag.NewFlagSet("curl", flag.ExitOnError)
+	conf := flags.String("conf", "conf.yml", "config file of the suite")
+	set := setFlag{}
+	flags.Var(set, "set", "value of a variable, e.g. a captured one (repeatable)")
+	args = parseArgs(flags, args)
+	if len(args) != 1 {
+		fmt.Println(curlUsage)
+		return 1
+	}
+
+	config, err := testerconfig.New().Load(*conf)
+	if err != nil {
+		fmt.Println("Cannot read config file : ", err)
+		return 2
+	}
+
+	client := testerclient.New(config.Url)
+	found := 0
+	for _, name := range config.GroupsOrder {
+		group := config.Groups[name]
+		tests := group.UnitTests
+		for _, scenario := range group.ScenarioOrder {
+			tests = append(tests, group.Scenarios[scenario]...)
+		}
+		for _, ut := range tests {
+			if ut.File != args[0] && !strings.HasPrefix(ut.File, args[0]+":") {
+				continue
+			}
+			found++
+			fmt.Println("# " + ut.File)
+			if !ut.Replayable() {
+				fmt.Printf("# %s action can't be replayed\n", ut.Action)
+				continue
+			}
+			env := map[string]string{}
+			for k, v := range group.Environment {
+				env[k] = v
+			}
+			for k, v := range set {
+				env[k] = v
+			}
+			if ut.In != nil {
+				ut.In = unittester.ReplaceWithEnvValue(ut.In, env)
+			}
+			request := unittester.BuildRequest(ut, env)
+			u := config.Url + request.Url
+			if unresolved := unresolvedVarRegexp.FindAllString(request.Url, -1); len(unresolved) > 0 {
+				fmt.Printf("# unresolved variables %s, use --set name=value\n", strings.Join(unresolved, " "))
+			} else {
+				u, err = client.Resolve(request.Url)
+				if err != nil {
+					fmt.Println("Cannot resolve url : ", err)
+					return 2
+				}
+			}
+			fmt.Println(unittester.CurlCommand(ut, request, u, env))
+		}
+	}
+	if found == 0 {
+		fmt.Println("No test found for", args[0])
+		return 2
+	}
+	return 0
+}
@@ -3,5 +3,9 @@ module github.com/madelyne-io/madelyne
 go 1.14
 
 require (
+	github.com/andybalholm/brotli v1.0.4
+	github.com/gorilla/websocket v1.4.2
+	google.golang.org/grpc v1.43.0
+	google.golang.org/protobuf v1.27.1
 	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
-)
\ No newline at end of file
+)
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/madelyne-io/madelyne/converter"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const importUsage = `usage: madelyne import postman collection.json [--out folder]
+       madelyne import http requests.http [--out folder] [--group name]`
+
+const exportUsage = "usage: madelyne export http conf.yml group [--out file.http]"
+
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	out := flags.String("out", ".", "folder where the converted suite is written")
+	group := flags.String("group", "", "name of the group created from a .http file")
+	args = parseArgs(flags, args)
+	if len(args) != 2 {
+		fmt.Println(importUsage)
+		return 1
+	}
+
+	data, err := ioutil.ReadFile(args[1])
+	if err != nil {
+		fmt.Println("Cannot read file : ", err)
+		return 2
+	}
+
+	var suite converter.Suite
+	var report []string
+	switch args[0] {
+	case "postman":
+		suite, report, err = converter.ImportPostman(data)
+	case "http":
+		name := *group
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(args[1]), filepath.Ext(args[1]))
+		}
+		dir := filepath.Dir(args[1])
+		suite, report, err = converter.ImportHttp(name, data, func(path string) ([]byte, error) {
+			return ioutil.ReadFile(filepath.Join(dir, path))
+		})
+	default:
+		fmt.Println("Unknown format", args[0])
+		fmt.Println(importUsage)
+		return 1
+	}
+	if err != nil {
+		fmt.Println("Cannot convert file : ", err)
+		return 2
+	}
+
+	err = suite.Write(*out)
+	if err != nil {
+		fmt.Println("Cannot write suite : ", err)
+		return 3
+	}
+	for _, g := range suite.Groups {
+		fmt.Printf("group %s : %d unit tests, %d scenarios\n", g.Name, len(g.UnitTests), len(g.Scenarios))
+	}
+	if len(report) > 0 {
+		fmt.Println("\nNot converted :")
+		for _, r := range report {
+			fmt.Println(" - " + r)
+		}
+	}
+	return 0
+}
+
+func runExport(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	out := flags.String("out", "", "file where the export is written, stdout by default")
+	args = parseArgs(flags, args)
+	if len(args) != 3 || args[0] != "http" {
+		fmt.Println(exportUsage)
+		return 1
+	}
+
+	config, err := testerconfig.New().Load(args[1])
+	if err != nil {
+		fmt.Println("Cannot read config file : ", err)
+		return 2
+	}
+	data, err := converter.ExportHttp(config, args[2])
+	if err != nil {
+		fmt.Println("Cannot export group : ", err)
+		return 2
+	}
+
+	if *out == "" {
+		os.Stdout.Write(data)
+		return 0
+	}
+	err = ioutil.WriteFile(*out, data, 0644)
+	if err != nil {
+		fmt.Println("Cannot write file : ", err)
+		return 3
+	}
+	return 0
+}
@@ -1,27 +1,102 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/madelyne-io/madelyne/tester"
+	"github.com/madelyne-io/madelyne/tester/testercoverage"
+	"github.com/madelyne-io/madelyne/tester/testerhar"
 	"os"
 )
 
 func main() {
-	if len(os.Args) <= 1 {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			os.Exit(runImport(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "curl":
+			os.Exit(runCurl(os.Args[2:]))
+		case "mock":
+			os.Exit(runMock(os.Args[2:]))
+		case "record":
+			os.Exit(runRecord(os.Args[2:]))
+		}
+	}
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	flags := flag.NewFlagSet("madelyne", flag.ExitOnError)
+	openapi := flags.String("openapi", "", "OpenAPI file used to report the covered endpoints")
+	coverageOut := flags.String("coverage-out", "", "write the endpoint coverage report as JSON in this file")
+	har := flags.String("har", "", "record all the traffic of the run in this HAR file")
+	args = parseArgs(flags, args)
+
+	if len(args) == 0 {
 		fmt.Println("You must provide a valid config file")
-		os.Exit(1)
+		return 1
 	}
 
-	suite, err := tester.Load(os.Args[1])
+	suite, err := tester.Load(args[0])
 	if err != nil {
 		fmt.Println("Cannot read config file : ", err)
-		os.Exit(2)
+		return 2
+	}
+
+	var coverage *testercoverage.Coverage
+	if *openapi != "" {
+		coverage, err = testercoverage.Load(*openapi)
+		if err != nil {
+			fmt.Println("Cannot read OpenAPI file : ", err)
+			return 2
+		}
+		suite.Wrap(coverage.Wrap)
+	}
+
+	var recorder *testerhar.Recorder
+	if *har != "" {
+		recorder = testerhar.New()
+		suite.Wrap(recorder.Wrap)
 	}
+
 	fmt.Println("Testing REST API with Madelyne")
 	err = suite.Run()
+	if recorder != nil {
+		werr := recorder.Write(*har)
+		if werr != nil {
+			fmt.Println("Cannot write HAR file : ", werr)
+		}
+	}
+	if coverage != nil {
+		report := coverage.Report()
+		report.Print(os.Stdout)
+		if *coverageOut != "" {
+			werr := report.WriteJSON(*coverageOut)
+			if werr != nil {
+				fmt.Println("Cannot write coverage report : ", werr)
+			}
+		}
+	}
 	if err != nil {
 		fmt.Println("\n\nError while running test: ", err)
-		os.Exit(3)
+		return 3
 	}
 	fmt.Println("Success")
+	return 0
+}
+
+// parseArgs allows flags to be given before or after the positional arguments.
+func parseArgs(flags *flag.FlagSet, args []string) []string {
+	positional := []string{}
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
 }
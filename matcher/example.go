@@ -0,0 +1,143 @@
+package matcher
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	exampleTextRegexp   = regexp.MustCompile("@([a-z]+)@")
+	exampleStringRegexp = regexp.MustCompile(`'([^']*)'|"([^"]*)"`)
+	exampleCallRegexp   = regexp.MustCompile(`([A-Za-z]+)\(([^)]*)\)`)
+	exampleNumberRegexp = regexp.MustCompile(`\(['"]?(\-?[0-9]+(?:\.[0-9]+)?)['"]?\)`)
+)
+
+// exampleDate is the date given to strings that must be a datetime.
+var exampleDate = time.Date(2024, time.January, 15, 9, 30, 0, 0, time.UTC)
+
+// Example gives a value matching the pattern, e.g. a random uuid for `@uuid@`
+// or a date for `@string@.isDateTime()`. A value that is not a pattern is
+// returned as is.
+func Example(pattern string) interface{} {
+	splitted := strings.Split(pattern, "@")
+	if len(splitted) < 3 {
+		return pattern
+	}
+	if splitted[0] != "" {
+		return exampleText(pattern)
+	}
+
+	program := strings.Join(splitted[2:], "@")
+	var candidates []interface{}
+	switch splitted[1] {
+	case "string":
+		candidates = exampleStrings(program)
+	case "number", "double", "integer":
+		candidates = exampleNumbers(splitted[1], program)
+	case "boolean":
+		return true
+	case "uuid":
+		return exampleUuid()
+	case "array":
+		return exampleArray(program)
+	default:
+		return pattern
+	}
+	for _, c := range candidates {
+		if matchPatter(c, pattern) == nil {
+			return c
+		}
+	}
+	return candidates[0]
+}
+
+func exampleText(pattern string) string {
+	return exampleTextRegexp.ReplaceAllStringFunc(pattern, func(p string) string {
+		switch p {
+		case "@string@", "@number@", "@double@", "@integer@", "@uuid@":
+			return fmt.Sprint(Example(p))
+		}
+		return p
+	})
+}
+
+func exampleStrings(program string) []interface{} {
+	candidates := []interface{}{"string"}
+	// startsWith, contains and endsWith are put together in this order
+	var start, middle, end, args []string
+	for _, call := range exampleCallRegexp.FindAllStringSubmatch(program, -1) {
+		m := exampleStringRegexp.FindStringSubmatch(call[2])
+		if m == nil {
+			continue
+		}
+		arg := m[1] + m[2]
+		args = append(args, arg)
+		switch call[1] {
+		case "startsWith":
+			start = append(start, arg)
+		case "contains":
+			middle = append(middle, arg)
+		case "endsWith":
+			end = append(end, arg)
+		}
+	}
+	if len(start)+len(middle)+len(end) > 0 {
+		parts := append(append(start, middle...), end...)
+		candidates = append(candidates, strings.Join(parts, " "), strings.Join(parts, ""))
+	}
+	candidates = append(candidates,
+		exampleDate.Format(time.RFC3339),
+		"user@example.com",
+		"https://example.com",
+		"",
+	)
+	// dates around the bounds of before() and after()
+	for _, a := range args {
+		t, err := parseTime(a)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates,
+			t.AddDate(0, 0, 1).Format(time.RFC3339),
+			t.AddDate(0, 0, -1).Format(time.RFC3339),
+		)
+	}
+	return candidates
+}
+
+func exampleNumbers(kind string, program string) []interface{} {
+	start := 1.0
+	if kind == "double" {
+		start = 1.5
+	}
+	candidates := []interface{}{start}
+	// numbers around the bounds of greaterThan() and lowerThan()
+	for _, m := range exampleNumberRegexp.FindAllStringSubmatch(program, -1) {
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, n+start, n-start)
+	}
+	return candidates
+}
+
+func exampleArray(program string) []interface{} {
+	m := exampleStringRegexp.FindStringSubmatch(program)
+	if m == nil || !strings.HasPrefix(program, ".repeat(") {
+		return []interface{}{}
+	}
+	return []interface{}{Example(m[1] + m[2])}
+}
+
+func exampleUuid() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
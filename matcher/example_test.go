@@ -0,0 +1,47 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExample(t *testing.T) {
+	patterns := []string{
+		"@string@",
+		"@string@.isDateTime()",
+		"@string@.isEmail()",
+		"@string@.isUrl()",
+		"@string@.isEmpty()",
+		"@string@.startsWith('You').endsWith('hello').contains('say goodbye')",
+		"@string@.after('2030-01-01')",
+		"@number@",
+		"@number@.greaterThan(10)",
+		"@integer@.lowerThan(-3)",
+		"@double@",
+		"@boolean@",
+		"@uuid@",
+		"@array@",
+		"@array@.repeat('@uuid@')",
+	}
+	for _, p := range patterns {
+		v := Example(p)
+		err := Match(v, p)
+		if err != nil {
+			t.Fatalf("%s : %v does not match : %v", p, v, err)
+		}
+	}
+
+	if Example("Bonjour !") != "Bonjour !" {
+		t.Fatalf("a value must be returned as is")
+	}
+	text := Example("/articles/@integer@")
+	if text != "/articles/1" {
+		t.Fatalf("got %v", text)
+	}
+	if v := Example("@array@.repeat('@string@')"); !reflect.DeepEqual(v, []interface{}{"string"}) {
+		t.Fatalf("got %#v", v)
+	}
+	if Example("@uuid@") == Example("@uuid@") {
+		t.Fatalf("uuids must be random")
+	}
+}
@@ -100,13 +100,13 @@ func (parser *Parser) parseFunction() (*ast.NodeFunction, error) {
 		case token.NUMBER:
 			{
 				parser.moveToNextToken()
-				v := &ast.NodeValue{parser.current}
+				v := &ast.NodeValue{Value: parser.current}
 				fn.Arguments = append(fn.Arguments, v)
 			}
 		case token.STRING:
 			{
 				parser.moveToNextToken()
-				v := &ast.NodeValue{parser.current}
+				v := &ast.NodeValue{Value: parser.current}
 				fn.Arguments = append(fn.Arguments, v)
 			}
 		default:
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"github.com/madelyne-io/madelyne/tester/testermock"
+	"net/http"
+)
+
+const mockUsage = "usage: madelyne mock conf.yml [--port 8080]"
+
+func runMock(args []string) int {
+	flags := flag.NewFlagSet("mock", flag.ExitOnError)
+	port := flags.Int("port", 8080, "port the mock server listens on")
+	args = parseArgs(flags, args)
+	if len(args) != 1 {
+		fmt.Println(mockUsage)
+		return 1
+	}
+
+	config, err := testerconfig.New().Load(args[0])
+	if err != nil {
+		fmt.Println("Cannot read config file : ", err)
+		return 2
+	}
+
+	server := testermock.New(config)
+	for _, r := range server.Routes() {
+		fmt.Printf("%s %s -> %d (%s)\n", r.Method, r.Path, r.Status, r.File)
+	}
+	fmt.Printf("Mock server listening on :%d\n", *port)
+	err = http.ListenAndServe(fmt.Sprintf(":%d", *port), server)
+	if err != nil {
+		fmt.Println("Cannot serve : ", err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/madelyne-io/madelyne/converter"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+const recordUsage = `usage: madelyne record --target http://localhost:3000 [--listen :9000] [--group recorded] [--scenario session] [--out folder]
+                       [--allow-header name ...] [--deny-header name ...]`
+
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func runRecord(args []string) int {
+	flags := flag.NewFlagSet("record", flag.ExitOnError)
+	listen := flags.String("listen", ":9000", "address the recording proxy listens on")
+	target := flags.String("target", "", "url of the API the traffic is forwarded to")
+	group := flags.String("group", "recorded", "name of the group created")
+	scenario := flags.String("scenario", "session", "name of the scenario holding the exchanges")
+	out := flags.String("out", ".", "folder where the recorded suite is written")
+	allow := listFlag{}
+	flags.Var(&allow, "allow-header", "request header recorded, all but the browser ones by default (repeatable)")
+	deny := listFlag{}
+	flags.Var(&deny, "deny-header", "request header recorded as a variable, in addition to "+strings.Join(converter.DefaultDeniedHeaders, ", ")+" (repeatable)")
+	args = parseArgs(flags, args)
+	if len(args) != 0 || *target == "" {
+		fmt.Println(recordUsage)
+		return 1
+	}
+
+	targetUrl, err := url.Parse(*target)
+	if err != nil || targetUrl.Scheme == "" || targetUrl.Host == "" {
+		fmt.Println("Invalid target url : ", *target)
+		return 1
+	}
+
+	recorder := converter.NewRecorder(targetUrl, allow, append(append([]string{}, converter.DefaultDeniedHeaders...), deny...))
+	recorder.Log = os.Stdout
+	err = recorder.Suite(*group, *scenario).Check(*out)
+	if err != nil {
+		fmt.Println("Cannot record in this folder : ", err)
+		return 1
+	}
+	server := &http.Server{Addr: *listen, Handler: recorder}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		server.Shutdown(context.Background())
+	}()
+
+	fmt.Printf("Recording %s on %s, press Ctrl-C to write the tests\n", *target, *listen)
+	err = server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		fmt.Println("Cannot serve : ", err)
+		return 2
+	}
+
+	steps := recorder.Steps()
+	if len(steps) == 0 {
+		fmt.Println("Nothing recorded")
+		return 0
+	}
+	suite := recorder.Suite(*group, *scenario)
+	err = suite.Write(*out)
+	if err != nil {
+		fmt.Println("Cannot write suite : ", err)
+		// the recording is kept anyway
+		dir, terr := ioutil.TempDir("", "madelyne-record")
+		if terr == nil && suite.Write(dir) == nil {
+			fmt.Println("Recording written in", dir)
+		}
+		return 3
+	}
+	fmt.Printf("group %s : %d steps recorded in scenario %s\n", *group, len(steps), *scenario)
+	return 0
+}
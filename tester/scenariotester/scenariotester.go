@@ -13,6 +13,9 @@ type UnitTester interface {
 type ScenarioTester struct {
 	Environment       map[string]string
 	UnitTesterBuilder func() UnitTester
+	// Close releases what the steps share once the scenario ended, it can be
+	// nil.
+	Close func()
 }
 
 func New(buildUnitTester func() UnitTester) *ScenarioTester {
@@ -27,6 +30,9 @@ func (t *ScenarioTester) Env() map[string]string {
 }
 
 func (t *ScenarioTester) RunMultiple(uts []testerconfig.UnitTest) error {
+	if t.Close != nil {
+		defer t.Close()
+	}
 
 	for i, ut := range uts {
 		unittester := t.UnitTesterBuilder()
@@ -147,3 +147,21 @@ func TestRunMultiple(t *testing.T) {
 		}
 	}
 }
+
+func TestRunMultipleClose(t *testing.T) {
+	ErrUnitTest := fmt.Errorf("ErrUnitTest")
+
+	for i, nextError := range []error{nil, ErrUnitTest} {
+		closed := 0
+		scenariotester := New(NextFakeUnitTesterBuilder([]fakeUnitTester{{nextError: nextError, nextEnv: map[string]string{}}}))
+		scenariotester.Close = func() { closed++ }
+
+		err := scenariotester.RunMultiple([]testerconfig.UnitTest{{}})
+		if !errors.Is(err, nextError) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, nextError)
+		}
+		if closed != 1 {
+			t.Fatalf("%d failed closed %d times", i, closed)
+		}
+	}
+}
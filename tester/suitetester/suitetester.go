@@ -17,6 +17,10 @@ type SuiteTester struct {
 	CommandLauncher       func(cmd string) error
 	UnitTesterBuilder     func(groupName string, env map[string]string) UnitTester
 	ScenarioTesterBuilder func(groupName string, env map[string]string) ScenarioTester
+	// GroupSetup is called before each group and gives the environment of its
+	// tests, the function it returns is called after the group. Nil when
+	// groups need no setup.
+	GroupSetup func(group testerconfig.TestGroup) (map[string]string, func(), error)
 }
 
 func (t *SuiteTester) runTest(setup string, teardown string, test func() error) error {
@@ -37,14 +41,7 @@ func (t *SuiteTester) runTest(setup string, teardown string, test func() error)
 
 func (t *SuiteTester) RunSuite(order []string, groups map[string]testerconfig.TestGroup) error {
 	for _, name := range order {
-		group := groups[name]
-		err := t.runTest(group.GlobalSetupCommand, group.GlobalTearDownCommand, func() error {
-			err := t.runGroupUnitTest(group)
-			if err != nil {
-				return err
-			}
-			return t.runGroupScenario(group)
-		})
+		err := t.runGroup(groups[name])
 		if err != nil {
 			return err
 		}
@@ -52,6 +49,24 @@ func (t *SuiteTester) RunSuite(order []string, groups map[string]testerconfig.Te
 	return nil
 }
 
+func (t *SuiteTester) runGroup(group testerconfig.TestGroup) error {
+	if t.GroupSetup != nil {
+		env, teardown, err := t.GroupSetup(group)
+		if err != nil {
+			return err
+		}
+		defer teardown()
+		group.Environment = env
+	}
+	return t.runTest(group.GlobalSetupCommand, group.GlobalTearDownCommand, func() error {
+		err := t.runGroupUnitTest(group)
+		if err != nil {
+			return err
+		}
+		return t.runGroupScenario(group)
+	})
+}
+
 func (t *SuiteTester) runGroupUnitTest(group testerconfig.TestGroup) error {
 	for _, ut := range group.UnitTests {
 		err := t.runTest(group.SetupCommand, group.TeardownCommand, func() error {
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"strings"
 	"testing"
 )
 
@@ -442,3 +443,37 @@ func TestRunGroupUnitTest(t *testing.T) {
 
 	}
 }
+
+func TestRunSuiteGroupSetup(t *testing.T) {
+	ErrFakeSetup := fmt.Errorf("ErrFakeSetup")
+	groups := map[string]testerconfig.TestGroup{
+		"group1": {GroupName: "group1", UnitTests: []testerconfig.UnitTest{{}}},
+		"group2": {GroupName: "group2", UnitTests: []testerconfig.UnitTest{{}}},
+	}
+
+	events := []string{}
+	tester := &SuiteTester{
+		UnitTesterBuilder: func(groupName string, env map[string]string) UnitTester {
+			events = append(events, "test "+groupName+" "+env["stub_payments"])
+			return &fakeTester{}
+		},
+		CommandLauncher: nopCommand,
+		ProgressLogger:  func() {},
+		GroupSetup: func(group testerconfig.TestGroup) (map[string]string, func(), error) {
+			if group.GroupName == "group2" {
+				return nil, nil, ErrFakeSetup
+			}
+			events = append(events, "setup "+group.GroupName)
+			env := map[string]string{"stub_payments": "http://localhost:1"}
+			return env, func() { events = append(events, "teardown "+group.GroupName) }, nil
+		},
+	}
+	err := tester.RunSuite([]string{"group1", "group2"}, groups)
+	if !errors.Is(err, ErrFakeSetup) {
+		t.Fatalf("got %v", err)
+	}
+	expected := "setup group1,test group1 http://localhost:1,teardown group1"
+	if strings.Join(events, ",") != expected {
+		t.Fatalf("got %v", events)
+	}
+}
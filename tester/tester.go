@@ -1,6 +1,7 @@
 package tester
 
 import (
+	"crypto/tls"
 	"github.com/madelyne-io/madelyne/comparator"
 	"github.com/madelyne-io/madelyne/tester/scenariotester"
 	"github.com/madelyne-io/madelyne/tester/suitetester"
@@ -8,15 +9,35 @@ import (
 	"github.com/madelyne-io/madelyne/tester/testercommand"
 	"github.com/madelyne-io/madelyne/tester/testerconfig"
 	"github.com/madelyne-io/madelyne/tester/testerfile"
+	"github.com/madelyne-io/madelyne/tester/testergrpc"
 	"github.com/madelyne-io/madelyne/tester/testerprogress"
+	"github.com/madelyne-io/madelyne/tester/testersmtp"
+	"github.com/madelyne-io/madelyne/tester/testerstub"
+	"github.com/madelyne-io/madelyne/tester/testerwebhook"
 	"github.com/madelyne-io/madelyne/tester/unittester"
+	"net/http"
 	"os"
+	"strconv"
 )
 
+type RequesterWrapper func(r testerclient.Requester) testerclient.Requester
+
 type Tester struct {
 	Suite       suitetester.SuiteTester
 	GroupsOrder []string
 	Groups      map[string]testerconfig.TestGroup
+	url         string
+	httpClient  *http.Client
+	tls         *tls.Config
+	grpc        *testergrpc.Client
+	wrappers    []RequesterWrapper
+	// stubs are the running stubs of the current group, by name.
+	stubs map[string]*testerstub.Server
+	// webhooks is nil when the suite expects no webhook.
+	webhookConfig *testerconfig.WebhookConfig
+	webhooks      *testerwebhook.Receiver
+	// smtp is the smtp server of the current group, nil when it has none.
+	smtp *testersmtp.Server
 }
 
 func Load(confFile string) (*Tester, error) {
@@ -31,43 +52,187 @@ func Load(confFile string) (*Tester, error) {
 }
 
 func Build(config testerconfig.Config, cmdLauncher func(cmd string) error) *Tester {
-	return &Tester{
+	t := &Tester{
 		Groups:      config.Groups,
 		GroupsOrder: config.GroupsOrder,
-		Suite: suitetester.SuiteTester{
-			CommandLauncher: cmdLauncher,
-			UnitTesterBuilder: func(groupName string, env map[string]string) suitetester.UnitTester {
+		url:         config.Url,
+		tls:         config.Http.Tls,
+		httpClient: testerclient.NewHttpClient(testerclient.TransportOptions{
+			MaxIdleConnections: config.Http.MaxIdleConnections,
+			KeepAlive:          config.Http.KeepAlive,
+			Http2:              config.Http.Http2,
+			Tls:                config.Http.Tls,
+		}),
+	}
+	t.webhookConfig = config.Webhooks
+	if config.Grpc != nil {
+		t.grpc = testergrpc.New(testergrpc.Options{
+			Address:     config.Grpc.Address,
+			Plaintext:   config.Grpc.Plaintext,
+			Tls:         config.Http.Tls,
+			Descriptors: config.Grpc.Descriptors,
+		})
+	}
+	t.Suite = suitetester.SuiteTester{
+		CommandLauncher: cmdLauncher,
+		UnitTesterBuilder: func(groupName string, env map[string]string) suitetester.UnitTester {
+			ut := unittester.New(
+				t.requester(nil),
+				comparator.New(groupName),
+				testerfile.New(),
+			)
+			if t.grpc != nil {
+				ut.Grpc = t.grpc
+			}
+			ut.Stubs = t.stubs
+			ut.Webhooks = t.webhooks
+			ut.Smtp = t.smtp
+			t.reset()
+			for k, v := range t.webhookEnv(env) {
+				ut.Env()[k] = v
+			}
+			return ut
+		},
+		ScenarioTesterBuilder: func(groupName string, env map[string]string) suitetester.ScenarioTester {
+			var jar *testerclient.Jar
+			// a nil *Jar would not be a nil http.CookieJar
+			var socketJar http.CookieJar
+			if t.Groups[groupName].Cookies {
+				jar = testerclient.NewJar()
+				socketJar = jar
+			}
+			socket := testerclient.NewSocket(t.url, t.tls, socketJar)
+			st := scenariotester.New(func() scenariotester.UnitTester {
 				ut := unittester.New(
-					testerclient.New(config.Url),
+					t.requester(jar),
 					comparator.New(groupName),
 					testerfile.New(),
 				)
-				for k, v := range env {
-					ut.Env()[k] = v
+				ut.Jar = jar
+				ut.Socket = socket
+				if t.grpc != nil {
+					ut.Grpc = t.grpc
 				}
+				ut.Stubs = t.stubs
+				ut.Webhooks = t.webhooks
+				ut.Smtp = t.smtp
 				return ut
-			},
-			ScenarioTesterBuilder: func(groupName string, env map[string]string) suitetester.ScenarioTester {
-				st := scenariotester.New(func() scenariotester.UnitTester {
-					return unittester.New(
-						testerclient.New(config.Url),
-						comparator.New(groupName),
-						testerfile.New(),
-					)
-				})
-				for k, v := range env {
-					st.Env()[k] = v
-				}
-				return st
-			},
+			})
+			t.reset()
+			st.Close = func() { socket.Close() }
+			for k, v := range t.webhookEnv(env) {
+				st.Env()[k] = v
+			}
+			return st
 		},
+		GroupSetup: t.setupGroup,
+	}
+	return t
+}
+
+// setupGroup starts the stubs and the smtp server of the group. Their
+// addresses are given to its tests as `stub_<name>`, `smtp_host` and
+// `smtp_port`, in a copy of the group environment.
+func (t *Tester) setupGroup(group testerconfig.TestGroup) (map[string]string, func(), error) {
+	env := map[string]string{}
+	for k, v := range group.Environment {
+		env[k] = v
+	}
+	t.stubs = map[string]*testerstub.Server{}
+	stop := func() {
+		for _, s := range t.stubs {
+			s.Close()
+		}
+		t.stubs = nil
+		if t.smtp != nil {
+			t.smtp.Close()
+			t.smtp = nil
+		}
+	}
+	for _, stub := range group.Stubs {
+		s, err := testerstub.Start(stub)
+		if err != nil {
+			stop()
+			return nil, nil, err
+		}
+		t.stubs[stub.Name] = s
+		env["stub_"+stub.Name] = s.Url()
+	}
+	if group.Smtp != nil {
+		s, err := testersmtp.Start(group.Smtp.Port)
+		if err != nil {
+			stop()
+			return nil, nil, err
+		}
+		t.smtp = s
+		env["smtp_host"] = s.Host()
+		env["smtp_port"] = strconv.Itoa(s.Port())
+	}
+	return env, stop, nil
+}
+
+// reset forgets the calls received by the stubs, the webhook receiver and the
+// smtp server before a unit test or a scenario.
+func (t *Tester) reset() {
+	for _, s := range t.stubs {
+		s.Reset()
 	}
+	if t.webhooks != nil {
+		t.webhooks.Reset()
+	}
+	if t.smtp != nil {
+		t.smtp.Reset()
+	}
+}
+
+// webhookEnv gives the environment of a group with the url of the webhook
+// receiver as `webhook_url`.
+func (t *Tester) webhookEnv(env map[string]string) map[string]string {
+	if t.webhooks == nil {
+		return env
+	}
+	out := map[string]string{"webhook_url": t.webhooks.Url()}
+	for k, v := range env {
+		out[k] = v
+	}
+	return out
+}
+
+// Wrap decorates every requester used by the suite, e.g. to observe the traffic.
+func (t *Tester) Wrap(wrapper RequesterWrapper) {
+	t.wrappers = append(t.wrappers, wrapper)
 }
 
 func (t *Tester) Run() error {
+	if t.grpc != nil {
+		defer t.grpc.Close()
+	}
+	if t.webhookConfig != nil {
+		receiver, err := testerwebhook.Start(t.webhookConfig.Port)
+		if err != nil {
+			return err
+		}
+		t.webhooks = receiver
+		defer func() {
+			receiver.Close()
+			t.webhooks = nil
+		}()
+	}
 	return t.Suite.RunSuite(t.GroupsOrder, t.Groups)
 }
 
+func (t *Tester) requester(jar *testerclient.Jar) testerclient.Requester {
+	client := testerclient.NewWithHttpClient(t.url, t.httpClient)
+	if jar != nil {
+		client = client.WithJar(jar)
+	}
+	var r testerclient.Requester = client
+	for _, wrap := range t.wrappers {
+		r = wrap(r)
+	}
+	return r
+}
+
 func countSteps(groups map[string]testerconfig.TestGroup) int {
 	count := 0
 	for _, g := range groups {
@@ -1 +1,31 @@
 package tester
+
+import (
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"testing"
+)
+
+func TestSetupGroup(t *testing.T) {
+	tests := []map[string]string{nil, {"token": "abc"}}
+
+	for i, env := range tests {
+		group := testerconfig.TestGroup{
+			GroupName:   "main",
+			Environment: env,
+			Stubs:       []testerconfig.Stub{{Name: "payments"}},
+			Smtp:        &testerconfig.SmtpConfig{},
+		}
+		tester := Build(testerconfig.Config{Url: "http://localhost"}, func(cmd string) error { return nil })
+		result, teardown, err := tester.setupGroup(group)
+		if err != nil {
+			t.Fatalf("%d failed %v", i, err)
+		}
+		teardown()
+		if result["stub_payments"] == "" || result["smtp_host"] != "localhost" || result["smtp_port"] == "" {
+			t.Fatalf("%d failed got %v", i, result)
+		}
+		if env != nil && (result["token"] != "abc" || len(env) != 1) {
+			t.Fatalf("%d failed got %v, group environment %v", i, result, env)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package testerclient
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,17 +13,32 @@ type Requester interface {
 }
 
 type Request struct {
+	Name    string
 	Method  string
 	Url     string
-	Headers map[string]string
+	Headers map[string][]string
 	Body    io.Reader
+	// MaxRedirects followed, after which the last response is returned. 0
+	// keeps the default of net/http, failing after 10 redirects, and a
+	// negative value follows none.
+	MaxRedirects int
 }
 
 type Response struct {
+	Url         string
 	StatusCode  int
 	Body        io.ReadCloser
 	ContentType string
 	Headers     map[string][]string
+	// TLS is nil when the connection is not encrypted.
+	TLS *tls.ConnectionState
+	// Redirects followed to get the response, in order.
+	Redirects []Redirect
+}
+
+type Redirect struct {
+	StatusCode int
+	Location   string
 }
 
 type Client struct {
@@ -31,41 +47,92 @@ type Client struct {
 }
 
 func New(baseUrl string) Client {
+	return NewWithHttpClient(baseUrl, &http.Client{})
+}
+
+// NewWithHttpClient gives a client sending its requests with httpClient, which
+// can be shared to reuse the connections.
+func NewWithHttpClient(baseUrl string, httpClient *http.Client) Client {
 	return Client{
-		httpClient: &http.Client{},
+		httpClient: httpClient,
 		baseUrl:    baseUrl,
 	}
 }
 
+// WithJar gives a client keeping the cookies of the responses in jar.
+func (c Client) WithJar(jar http.CookieJar) Client {
+	httpClient := *c.httpClient
+	httpClient.Jar = jar
+	c.httpClient = &httpClient
+	return c
+}
+
+// Resolve gives the url really called for the path of a request.
+func (c Client) Resolve(u string) (string, error) {
+	u, err := encodeUrl(u)
+	if err != nil {
+		return "", err
+	}
+	return c.baseUrl + u, nil
+}
+
+// Make sends the request. The resolved url is set in the response even when the
+// request fails, to help reproducing it.
 func (c Client) Make(r Request) (Response, error) {
-	u, err := encodeUrl(r.Url)
+	u, err := c.Resolve(r.Url)
 	if err != nil {
 		return Response{}, err
 	}
 
-	request, err := http.NewRequest(r.Method, c.baseUrl+u, r.Body)
+	request, err := http.NewRequest(r.Method, u, r.Body)
 	if err != nil {
-		return Response{}, err
+		return Response{Url: u}, err
 	}
 
-	for key, value := range r.Headers {
-		request.Header.Add(key, value)
+	for key, values := range r.Headers {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
 	}
 
-	response, err := c.httpClient.Do(request)
+	redirects := []Redirect{}
+	httpClient := *c.httpClient
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		switch {
+		case r.MaxRedirects == 0 && len(via) >= 10:
+			// same error as the default policy of net/http
+			return fmt.Errorf("stopped after 10 redirects")
+		case r.MaxRedirects < 0 || (r.MaxRedirects > 0 && len(via) > r.MaxRedirects):
+			return http.ErrUseLastResponse
+		}
+		redirects = append(redirects, Redirect{
+			StatusCode: req.Response.StatusCode,
+			Location:   req.Response.Header.Get("Location"),
+		})
+		return nil
+	}
+
+	response, err := httpClient.Do(request)
 	if err != nil {
-		return Response{}, err
+		return Response{Url: u, Redirects: redirects}, err
 	}
 
+	// net/http only decodes the body when it asked for gzip itself, the
+	// Content-Encoding header is kept to be checked.
+	body := decodeBody(response.Header.Get("Content-Encoding"), response.Body)
+
 	return Response{
+		Url:         u,
 		StatusCode:  response.StatusCode,
-		Body:        response.Body,
+		Body:        body,
 		ContentType: response.Header.Get("Content-Type"),
 		Headers:     response.Header,
+		TLS:         response.TLS,
+		Redirects:   redirects,
 	}, nil
 }
 
-func (c Client) Get(url string, headers map[string]string) (Response, error) {
+func (c Client) Get(url string, headers map[string][]string) (Response, error) {
 	return c.Make(Request{
 		Method:  "GET",
 		Url:     url,
@@ -73,7 +140,7 @@ func (c Client) Get(url string, headers map[string]string) (Response, error) {
 	})
 }
 
-func (c Client) Post(url string, body io.Reader, headers map[string]string) (Response, error) {
+func (c Client) Post(url string, body io.Reader, headers map[string][]string) (Response, error) {
 	return c.Make(Request{
 		Method:  "POST",
 		Url:     url,
@@ -82,7 +149,7 @@ func (c Client) Post(url string, body io.Reader, headers map[string]string) (Res
 	})
 }
 
-func (c Client) Put(url string, body io.Reader, headers map[string]string) (Response, error) {
+func (c Client) Put(url string, body io.Reader, headers map[string][]string) (Response, error) {
 	return c.Make(Request{
 		Method:  "PUT",
 		Url:     url,
@@ -91,7 +158,7 @@ func (c Client) Put(url string, body io.Reader, headers map[string]string) (Resp
 	})
 }
 
-func (c Client) Patch(url string, body io.Reader, headers map[string]string) (Response, error) {
+func (c Client) Patch(url string, body io.Reader, headers map[string][]string) (Response, error) {
 	return c.Make(Request{
 		Method:  "PATCH",
 		Url:     url,
@@ -100,7 +167,7 @@ func (c Client) Patch(url string, body io.Reader, headers map[string]string) (Re
 	})
 }
 
-func (c Client) Delete(url string, headers map[string]string) (Response, error) {
+func (c Client) Delete(url string, headers map[string][]string) (Response, error) {
 	return c.Make(Request{
 		Method:  "DELETE",
 		Url:     url,
@@ -1 +1,27 @@
 package testerclient
+
+import (
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		baseUrl  string
+		input    string
+		expected string
+	}{
+		{"http://localhost:3000", "/articles", "http://localhost:3000/articles"},
+		{"http://localhost:3000", "/articles?b=2&a=1", "http://localhost:3000/articles?a=1&b=2"},
+		{"http://localhost:3000/api", "/articles?q=a b", "http://localhost:3000/api/articles?q=a+b"},
+	}
+
+	for i, tt := range tests {
+		result, err := New(tt.baseUrl).Resolve(tt.input)
+		if err != nil {
+			t.Fatalf("%d : failed %v", i, err)
+		}
+		if result != tt.expected {
+			t.Fatalf("%d : failed got %s exp %s", i, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package testerclient
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"github.com/andybalholm/brotli"
+	"io"
+	"strings"
+)
+
+var ErrDecodeBody = fmt.Errorf("Can't decode the response body")
+
+// decodedBody decompresses the body received on its first read, so an empty
+// body of a HEAD request is not an error as long as it is not read.
+type decodedBody struct {
+	body   io.ReadCloser
+	decode func(io.Reader) (io.Reader, error)
+	reader io.Reader
+}
+
+func (d *decodedBody) Read(p []byte) (int, error) {
+	if d.reader == nil {
+		r, err := d.decode(d.body)
+		if err != nil {
+			return 0, fmt.Errorf("%w : %v", ErrDecodeBody, err)
+		}
+		d.reader = r
+	}
+	return d.reader.Read(p)
+}
+
+func (d *decodedBody) Close() error {
+	if c, ok := d.reader.(io.Closer); ok {
+		c.Close()
+	}
+	return d.body.Close()
+}
+
+// decodeBody decompresses a body encoded with gzip, deflate or br. Other
+// encodings, identity included, are returned as they are.
+func decodeBody(encoding string, body io.ReadCloser) io.ReadCloser {
+	var decode func(io.Reader) (io.Reader, error)
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		decode = func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }
+	case "deflate":
+		decode = func(r io.Reader) (io.Reader, error) { return flate.NewReader(r), nil }
+	case "br":
+		decode = func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil }
+	default:
+		return body
+	}
+	return &decodedBody{body: body, decode: decode}
+}
@@ -0,0 +1,78 @@
+package testerclient
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"github.com/andybalholm/brotli"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMakeDecodesBody(t *testing.T) {
+	encoders := map[string]func(w io.Writer) io.WriteCloser{
+		"gzip": func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		},
+		"br": func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := r.Header.Get("Accept-Encoding")
+		if encoding == "broken" {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write([]byte("not gzip"))
+			return
+		}
+		encoder, ok := encoders[encoding]
+		if !ok {
+			w.Write([]byte(`{"a":1}`))
+			return
+		}
+		w.Header().Set("Content-Encoding", encoding)
+		if r.Method == "HEAD" {
+			return
+		}
+		ew := encoder(w)
+		ew.Write([]byte(`{"a":1}`))
+		ew.Close()
+	}))
+	defer server.Close()
+
+	client := New(server.URL)
+	for _, encoding := range []string{"gzip", "deflate", "br", ""} {
+		r, err := client.Make(Request{Method: "GET", Url: "/", Headers: map[string][]string{"Accept-Encoding": {encoding}}})
+		if err != nil {
+			t.Fatalf("%s failed %v", encoding, err)
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil || !bytes.Equal(body, []byte(`{"a":1}`)) {
+			t.Fatalf("%s failed got %s, %v", encoding, body, err)
+		}
+		if http.Header(r.Headers).Get("Content-Encoding") != encoding {
+			t.Fatalf("%s failed Content-Encoding %v", encoding, r.Headers)
+		}
+	}
+
+	r, err := client.Make(Request{Method: "HEAD", Url: "/", Headers: map[string][]string{"Accept-Encoding": {"gzip"}}})
+	if err != nil {
+		t.Fatalf("HEAD failed %v", err)
+	}
+	r.Body.Close()
+
+	r, err = client.Make(Request{Method: "GET", Url: "/", Headers: map[string][]string{"Accept-Encoding": {"broken"}}})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	_, err = ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if !errors.Is(err, ErrDecodeBody) {
+		t.Fatalf("failed got %v, exp %v", err, ErrDecodeBody)
+	}
+}
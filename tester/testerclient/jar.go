@@ -0,0 +1,40 @@
+package testerclient
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+)
+
+// Jar is a cookie jar which can be emptied, e.g. to log out in the middle of a
+// scenario.
+type Jar struct {
+	mutex sync.Mutex
+	jar   *cookiejar.Jar
+}
+
+func NewJar() *Jar {
+	j := &Jar{}
+	j.Clear()
+	return j
+}
+
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.jar.SetCookies(u, cookies)
+}
+
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.jar.Cookies(u)
+}
+
+func (j *Jar) Clear() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	// cookiejar.New only fails on invalid options
+	j.jar, _ = cookiejar.New(nil)
+}
@@ -0,0 +1,52 @@
+package testerclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+			return
+		}
+		c, err := r.Cookie("session")
+		if err != nil {
+			w.Write([]byte("anonymous"))
+			return
+		}
+		w.Write([]byte(c.Value))
+	}))
+	defer server.Close()
+
+	jar := NewJar()
+	client := New(server.URL).WithJar(jar)
+	me := func() string {
+		r, err := client.Get("/me", nil)
+		if err != nil {
+			t.Fatalf("failed %v", err)
+		}
+		defer r.Body.Close()
+		body, _ := ioutil.ReadAll(r.Body)
+		return string(body)
+	}
+
+	if got := me(); got != "anonymous" {
+		t.Fatalf("failed got %s", got)
+	}
+	r, err := client.Get("/login", nil)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	r.Body.Close()
+	if got := me(); got != "abc" {
+		t.Fatalf("failed got %s", got)
+	}
+	jar.Clear()
+	if got := me(); got != "anonymous" {
+		t.Fatalf("failed got %s after clear", got)
+	}
+}
@@ -0,0 +1,69 @@
+package testerclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMakeRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.Redirect(w, r, "/home", http.StatusFound)
+		case "/home":
+			http.Redirect(w, r, "/dashboard", http.StatusMovedPermanently)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		maxRedirects      int
+		expectedStatus    int
+		expectedRedirects []Redirect
+	}{
+		{0, 200, []Redirect{{302, "/home"}, {301, "/dashboard"}}},
+		{-1, 302, []Redirect{}},
+		{1, 301, []Redirect{{302, "/home"}}},
+		{2, 200, []Redirect{{302, "/home"}, {301, "/dashboard"}}},
+	}
+
+	client := New(server.URL)
+	for i, tt := range tests {
+		r, err := client.Make(Request{Method: "GET", Url: "/login", MaxRedirects: tt.maxRedirects})
+		if err != nil {
+			t.Fatalf("%d failed %v", i, err)
+		}
+		r.Body.Close()
+		if r.StatusCode != tt.expectedStatus {
+			t.Fatalf("%d failed got %d exp %d", i, r.StatusCode, tt.expectedStatus)
+		}
+		if !reflect.DeepEqual(r.Redirects, tt.expectedRedirects) {
+			t.Fatalf("%d failed got %v exp %v", i, r.Redirects, tt.expectedRedirects)
+		}
+	}
+}
+
+func TestMakeRedirectLoop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := New(server.URL)
+	_, err := client.Make(Request{Method: "GET", Url: "/loop"})
+	if err == nil || !strings.Contains(err.Error(), "stopped after 10 redirects") {
+		t.Fatalf("failed a redirect loop should be reported, got %v", err)
+	}
+
+	r, err := client.Make(Request{Method: "GET", Url: "/loop", MaxRedirects: 3})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	r.Body.Close()
+	if r.StatusCode != http.StatusFound || len(r.Redirects) != 3 {
+		t.Fatalf("failed got %d after %v", r.StatusCode, r.Redirects)
+	}
+}
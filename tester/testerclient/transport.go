@@ -0,0 +1,36 @@
+package testerclient
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+type TransportOptions struct {
+	// MaxIdleConnections kept open, per host as the suite calls one api. 0
+	// keeps the default of net/http.
+	MaxIdleConnections int
+	KeepAlive          bool
+	Http2              bool
+	// Tls replaces the default tls settings when it is not nil.
+	Tls *tls.Config
+}
+
+// NewHttpClient gives a client whose transport is meant to be shared by every
+// request of the suite.
+func NewHttpClient(o TransportOptions) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if o.MaxIdleConnections > 0 {
+		transport.MaxIdleConns = o.MaxIdleConnections
+		transport.MaxIdleConnsPerHost = o.MaxIdleConnections
+	}
+	transport.DisableKeepAlives = !o.KeepAlive
+	if o.Tls != nil {
+		transport.TLSClientConfig = o.Tls.Clone()
+	}
+	if !o.Http2 {
+		transport.ForceAttemptHTTP2 = false
+		// a non nil empty map disables HTTP/2
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,73 @@
+package testerclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewHttpClient(t *testing.T) {
+	tests := []struct {
+		options         TransportOptions
+		expectedIdle    int
+		expectedNoReuse bool
+		expectedNoHttp2 bool
+	}{
+		{TransportOptions{KeepAlive: true, Http2: true}, 2, false, false},
+		{TransportOptions{MaxIdleConnections: 10, KeepAlive: false, Http2: false}, 10, true, true},
+	}
+
+	for i, tt := range tests {
+		transport := NewHttpClient(tt.options).Transport.(*http.Transport)
+		idle := transport.MaxIdleConnsPerHost
+		if idle == 0 {
+			idle = http.DefaultMaxIdleConnsPerHost
+		}
+		if idle != tt.expectedIdle {
+			t.Fatalf("%d failed got %d idle connections exp %d", i, idle, tt.expectedIdle)
+		}
+		if transport.DisableKeepAlives != tt.expectedNoReuse {
+			t.Fatalf("%d failed got keep alive disabled %t", i, transport.DisableKeepAlives)
+		}
+		noHttp2 := transport.TLSNextProto != nil && len(transport.TLSNextProto) == 0
+		if noHttp2 != tt.expectedNoHttp2 || transport.ForceAttemptHTTP2 == tt.expectedNoHttp2 {
+			t.Fatalf("%d failed got http2 disabled %t", i, noHttp2)
+		}
+	}
+}
+
+func TestNewHttpClientTls(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+	ca := x509.NewCertPool()
+	ca.AddCert(server.Certificate())
+
+	tests := []struct {
+		tls         *tls.Config
+		expectedErr bool
+	}{
+		{tls: nil, expectedErr: true},
+		{tls: &tls.Config{RootCAs: ca}},
+		{tls: &tls.Config{InsecureSkipVerify: true}},
+		{tls: &tls.Config{RootCAs: ca, MinVersion: tls.VersionTLS13}, expectedErr: true},
+	}
+
+	for i, tt := range tests {
+		client := NewWithHttpClient(server.URL, NewHttpClient(TransportOptions{KeepAlive: true, Http2: true, Tls: tt.tls}))
+		r, err := client.Get("/", nil)
+		if (err != nil) != tt.expectedErr {
+			t.Fatalf("%d failed got %v", i, err)
+		}
+		if err != nil {
+			continue
+		}
+		r.Body.Close()
+		if r.TLS == nil || r.TLS.Version != tls.VersionTLS12 {
+			t.Fatalf("%d failed got tls state %#v", i, r.TLS)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package testerclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var ErrSocketNotConnected = fmt.Errorf("No websocket connected, a WS_CONNECT step is expected first")
+
+// Socket keeps the websocket connection of a scenario between its steps.
+type Socket struct {
+	baseUrl string
+	dialer  websocket.Dialer
+	conn    *websocket.Conn
+}
+
+// NewSocket gives a socket connecting to the api at baseUrl, http being
+// replaced by ws. jar can be nil when cookies are not kept.
+func NewSocket(baseUrl string, tlsConfig *tls.Config, jar http.CookieJar) *Socket {
+	s := &Socket{
+		baseUrl: "ws" + strings.TrimPrefix(baseUrl, "http"),
+		dialer: websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: 45 * time.Second,
+			Jar:              jar,
+		},
+	}
+	if tlsConfig != nil {
+		s.dialer.TLSClientConfig = tlsConfig.Clone()
+	}
+	return s
+}
+
+// Connect opens the connection, closing the previous one. The response of the
+// handshake is given even when it is refused, to check its status.
+func (s *Socket) Connect(r Request) (Response, error) {
+	s.Close()
+	u, err := encodeUrl(r.Url)
+	if err != nil {
+		return Response{}, err
+	}
+	u = s.baseUrl + u
+
+	headers := http.Header{}
+	for key, values := range r.Headers {
+		// the handshake sets its own headers
+		if strings.EqualFold(key, "Content-Type") {
+			continue
+		}
+		for _, value := range values {
+			headers.Add(key, value)
+		}
+	}
+
+	conn, response, err := s.dialer.Dial(u, headers)
+	if response == nil {
+		return Response{Url: u}, err
+	}
+	s.conn = conn
+	return Response{
+		Url:         u,
+		StatusCode:  response.StatusCode,
+		Body:        response.Body,
+		ContentType: response.Header.Get("Content-Type"),
+		Headers:     response.Header,
+		TLS:         response.TLS,
+	}, nil
+}
+
+// Send writes a text message, or a binary one when data is not text.
+func (s *Socket) Send(data []byte, binary bool) error {
+	if s.conn == nil {
+		return ErrSocketNotConnected
+	}
+	messageType := websocket.TextMessage
+	if binary {
+		messageType = websocket.BinaryMessage
+	}
+	return s.conn.WriteMessage(messageType, data)
+}
+
+// Receive gives the next message, waiting for it at most timeout.
+func (s *Socket) Receive(timeout time.Duration) ([]byte, error) {
+	if s.conn == nil {
+		return nil, ErrSocketNotConnected
+	}
+	s.conn.SetReadDeadline(time.Now().Add(timeout))
+	_, data, err := s.conn.ReadMessage()
+	return data, err
+}
+
+// Close sends a close message then closes the connection, it does nothing
+// when no connection is open.
+func (s *Socket) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	conn := s.conn
+	s.conn = nil
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+	return conn.Close()
+}
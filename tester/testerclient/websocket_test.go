@@ -0,0 +1,70 @@
+package testerclient
+
+import (
+	"errors"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSocket(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(messageType, append([]byte(r.URL.Query().Get("prefix")), data...))
+		}
+	}))
+	defer server.Close()
+
+	socket := NewSocket(server.URL, nil, nil)
+	err := socket.Send([]byte("a"), false)
+	if !errors.Is(err, ErrSocketNotConnected) {
+		t.Fatalf("failed got %v, exp %v", err, ErrSocketNotConnected)
+	}
+
+	r, err := socket.Connect(Request{Url: "/ws", Headers: map[string][]string{}})
+	if err != nil || r.StatusCode != http.StatusForbidden {
+		t.Fatalf("failed got %d, %v", r.StatusCode, err)
+	}
+
+	r, err = socket.Connect(Request{Url: "/ws?prefix=echo:", Headers: map[string][]string{"Authorization": {"Bearer abc"}, "Content-Type": {"application/json"}}})
+	if err != nil || r.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("failed got %d, %v", r.StatusCode, err)
+	}
+	err = socket.Send([]byte(`{"a":1}`), false)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	data, err := socket.Receive(time.Second)
+	if err != nil || string(data) != `echo:{"a":1}` {
+		t.Fatalf("failed got %s, %v", data, err)
+	}
+	_, err = socket.Receive(10 * time.Millisecond)
+	if err == nil {
+		t.Fatalf("failed a message was received")
+	}
+
+	err = socket.Close()
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	_, err = socket.Receive(time.Second)
+	if !errors.Is(err, ErrSocketNotConnected) {
+		t.Fatalf("failed got %v, exp %v", err, ErrSocketNotConnected)
+	}
+}
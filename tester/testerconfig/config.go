@@ -1,21 +1,51 @@
 package testerconfig
 
 import (
+	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"gopkg.in/yaml.v3"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 )
 
+var (
+	ErrUnknownAction   = fmt.Errorf("Unknown unit_tests key, expected an upper case HTTP method")
+	ErrInvalidCapture  = fmt.Errorf("Invalid header capture, expected `Header` or `Header: regexp`")
+	ErrInvalidSameSite = fmt.Errorf("Invalid sameSite, expected Lax, Strict or None")
+	ErrInvalidEncoding = fmt.Errorf("Invalid contentEncoding, only gzip is supported")
+	ErrInvalidTimeout  = fmt.Errorf("Invalid timeout, expected a duration like 5s")
+)
+
+// DefaultTimeout is the time waited for a message that may never come.
+const DefaultTimeout = 10 * time.Second
+
 type Config struct {
 	Url         string
 	GroupsOrder []string
 	Groups      map[string]TestGroup
+	Http        HttpConfig
+	// Grpc is nil when the suite has no GRPC tests.
+	Grpc *GrpcConfig
+	// Webhooks is nil when no webhook receiver is needed.
+	Webhooks *WebhookConfig
+}
+
+// HttpConfig tunes the connections shared by every request of the suite.
+type HttpConfig struct {
+	MaxIdleConnections int
+	KeepAlive          bool
+	Http2              bool
+	// Tls is nil when the default settings are used.
+	Tls *tls.Config
 }
 
 type TestGroup struct {
@@ -28,6 +58,12 @@ type TestGroup struct {
 	UnitTests             []UnitTest
 	ScenarioOrder         []string
 	Scenarios             map[string][]UnitTest
+	// Cookies gives a cookie jar to each scenario of the group.
+	Cookies bool
+	// Stubs are started for the group, sorted by name.
+	Stubs []Stub
+	// Smtp is nil when the group needs no SMTP server.
+	Smtp *SmtpConfig
 }
 
 type UnitTest struct {
@@ -35,14 +71,115 @@ type UnitTest struct {
 	Action  string
 	Url     string
 	Status  int
-	Headers map[string]string
+	Headers map[string][]string
+	Query   map[string][]string
 	In      []byte
 	InName  string
+	InFile  string
 	Out     []byte
 	OutName string
 	CtIn    string
 	CtOut   string
 	Pcre    string
+
+	// OutHeaders are the expected response headers, values being literals or
+	// matcher patterns. AbsentHeaders must not be in the response.
+	OutHeaders    map[string]string
+	AbsentHeaders []string
+	// CaptureHeaders gives the header, and the regexp applied on it, for each
+	// variable captured in the environment.
+	CaptureHeaders map[string]HeaderCapture
+	// OutCookies are the expected cookies set by the response, AbsentCookies
+	// must not be set. ClearCookies empties the jar before the request.
+	OutCookies    map[string]CookieAssertion
+	AbsentCookies []string
+	ClearCookies  bool
+	// OutTls checks the tls connection of the response, nil when not checked.
+	OutTls *TlsAssertion
+	// MaxRedirects has the meaning of testerclient.Request.MaxRedirects.
+	// OutRedirects is the expected chain of redirects, nil when not checked.
+	MaxRedirects int
+	OutRedirects []RedirectAssertion
+	// Form is sent as a multipart/form-data body instead of In.
+	Form *Form
+	// FormUrlencoded is sent as an application/x-www-form-urlencoded body
+	// instead of In, nil when not set.
+	FormUrlencoded map[string][]string
+
+	// AcceptEncoding is sent as the Accept-Encoding header, the response body
+	// is then decoded before being compared. OutEncoding is the expected
+	// Content-Encoding of the response, identity when it must not be encoded.
+	AcceptEncoding string
+	OutEncoding    string
+	// ContentEncoding compresses the body sent, only gzip is supported.
+	ContentEncoding string
+	// Sse reads the response as a stream of events instead of comparing Out,
+	// nil when the response is not a stream.
+	Sse *SseAssertion
+	// Timeout is the time a WS_EXPECT step waits for the next message, 0
+	// waits DefaultTimeout.
+	Timeout time.Duration
+	// OutErrors are the expected errors of a GRAPHQL test, whose Out is the
+	// expected data. When nil the response must have no errors.
+	OutErrors []byte
+	// GrpcStatus is the expected status name of a GRPC test, OK by default.
+	GrpcStatus string
+	// Stub is checked by a STUB step, nil for the other actions.
+	Stub *StubAssertion
+	// Signature is the HMAC checked by an EXPECT_WEBHOOK step, nil when not
+	// checked.
+	Signature *SignatureAssertion
+	// Email is checked by an EXPECT_EMAIL step, nil for the other actions.
+	Email *EmailAssertion
+}
+
+// Replayable tells if the test is a single http request, which FILE, GRPC,
+// STUB, EXPECT_WEBHOOK, EXPECT_EMAIL and websocket steps are not.
+func (u UnitTest) Replayable() bool {
+	switch u.Action {
+	case "FILE", "GRPC", "STUB", "EXPECT_WEBHOOK", "EXPECT_EMAIL":
+		return false
+	}
+	return !strings.HasPrefix(u.Action, "WS_")
+}
+
+// Method gives the http method of the request sent for the test.
+func (u UnitTest) Method() string {
+	if u.Action == "GRAPHQL" {
+		return "POST"
+	}
+	return u.Action
+}
+
+// RedirectAssertion describes a followed redirect, Location being a literal or a
+// matcher pattern. Empty fields are not checked.
+type RedirectAssertion struct {
+	Status   int    `yaml:"status"`
+	Location string `yaml:"location"`
+}
+
+// TlsAssertion describes the negotiated tls connection. Version is 1.0 to 1.3,
+// Subject is a literal or matcher pattern of the peer certificate subject
+// (`CN=api.internal,O=Acme`). Empty fields are not checked.
+type TlsAssertion struct {
+	Version string `yaml:"version"`
+	Subject string `yaml:"subject"`
+}
+
+// CookieAssertion describes a cookie set by a response. Value and Expires are
+// literals or matcher patterns, Expires being the remaining lifetime in seconds
+// or `session`. Empty fields are not checked.
+type CookieAssertion struct {
+	Value    string `yaml:"value"`
+	HttpOnly *bool  `yaml:"httpOnly"`
+	Secure   *bool  `yaml:"secure"`
+	SameSite string `yaml:"sameSite"`
+	Expires  string `yaml:"expires"`
+}
+
+type HeaderCapture struct {
+	Header string
+	Regexp string
 }
 
 type ConfigLoader struct {
@@ -58,8 +195,35 @@ func New() ConfigLoader {
 }
 
 type ymlConfig struct {
-	Url    string                  `yaml:"url"`
-	Groups map[string]ymlTestGroup `yaml:"groups"`
+	Url     string                  `yaml:"url"`
+	Cookies bool                    `yaml:"cookies"`
+	Http    ymlHttpConfig           `yaml:"http"`
+	Grpc    *ymlGrpcConfig          `yaml:"grpc"`
+	Webhook *ymlWebhookConfig       `yaml:"webhooks"`
+	Groups  map[string]ymlTestGroup `yaml:"groups"`
+}
+
+type ymlHttpConfig struct {
+	MaxIdleConnections int   `yaml:"maxIdleConnections"`
+	KeepAlive          *bool `yaml:"keepAlive"`
+	Http2              *bool `yaml:"http2"`
+
+	Tls *ymlTlsConfig `yaml:"tls"`
+}
+
+func (yh ymlHttpConfig) toHttpConfig() HttpConfig {
+	out := HttpConfig{
+		MaxIdleConnections: yh.MaxIdleConnections,
+		KeepAlive:          true,
+		Http2:              true,
+	}
+	if yh.KeepAlive != nil {
+		out.KeepAlive = *yh.KeepAlive
+	}
+	if yh.Http2 != nil {
+		out.Http2 = *yh.Http2
+	}
+	return out
 }
 
 type ymlTestGroup struct {
@@ -69,6 +233,10 @@ type ymlTestGroup struct {
 	TeardownCommand       string   `yaml:"teardownCommand"`
 	Environment           string   `yaml:"environment"`
 	Tests                 []string `yaml:"tests"`
+	Cookies               *bool    `yaml:"cookies"`
+
+	Stubs map[string]ymlStub `yaml:"stubs"`
+	Smtp  *ymlSmtpConfig     `yaml:"smtp"`
 }
 
 func (cl ConfigLoader) loadFile(filename string) ([]byte, error) {
@@ -87,7 +255,7 @@ func (cl ConfigLoader) Load(filename string) (Config, error) {
 		return Config{}, err
 	}
 	yc := ymlConfig{}
-	err = yaml.Unmarshal([]byte(data), &yc)
+	err = yaml.Unmarshal(data, &yc)
 	if err != nil {
 		return Config{}, fmt.Errorf("cannot unmarshal file %s : %w", filename, err)
 	}
@@ -95,6 +263,15 @@ func (cl ConfigLoader) Load(filename string) (Config, error) {
 	config := Config{
 		Url:    yc.Url,
 		Groups: map[string]TestGroup{},
+		Http:   yc.Http.toHttpConfig(),
+	}
+	config.Http.Tls, err = cl.loadTls(yc.Http.Tls)
+	if err != nil {
+		return Config{}, fmt.Errorf("while loading tls configuration : %w", err)
+	}
+	config.Grpc, err = cl.loadGrpc(yc.Grpc, yc.Url)
+	if err != nil {
+		return Config{}, fmt.Errorf("while loading grpc configuration : %w", err)
 	}
 	for k, v := range yc.Groups {
 		env, err := cl.loadEnvFile(k, v.Environment)
@@ -105,11 +282,27 @@ func (cl ConfigLoader) Load(filename string) (Config, error) {
 		if err != nil {
 			return Config{}, fmt.Errorf("while loading tests of group %s : %w", k, err)
 		}
+		stubs, err := cl.loadStubs(k, v.Stubs)
+		if err != nil {
+			return Config{}, fmt.Errorf("while loading stubs of group %s : %w", k, err)
+		}
+		err = checkStubSteps(stubs, units, scenarios)
+		if err != nil {
+			return Config{}, fmt.Errorf("in group %s : %w", k, err)
+		}
+		smtp, err := loadSmtp(v.Smtp, units, scenarios)
+		if err != nil {
+			return Config{}, fmt.Errorf("in group %s : %w", k, err)
+		}
 		sOrder := make([]string, 0, len(scenarios))
 		for k := range scenarios {
 			sOrder = append(sOrder, k)
 		}
 		sort.Strings(sOrder)
+		cookies := yc.Cookies
+		if v.Cookies != nil {
+			cookies = *v.Cookies
+		}
 		config.Groups[k] = TestGroup{
 			GroupName:             k,
 			GlobalSetupCommand:    v.GlobalSetupCommand,
@@ -120,6 +313,9 @@ func (cl ConfigLoader) Load(filename string) (Config, error) {
 			UnitTests:             units,
 			ScenarioOrder:         sOrder,
 			Scenarios:             scenarios,
+			Cookies:               cookies,
+			Stubs:                 stubs,
+			Smtp:                  smtp,
 		}
 	}
 	gOrder := make([]string, 0, len(config.Groups))
@@ -128,6 +324,10 @@ func (cl ConfigLoader) Load(filename string) (Config, error) {
 	}
 	sort.Strings(gOrder)
 	config.GroupsOrder = gOrder
+	config.Webhooks, err = loadWebhooks(yc.Webhook, config.Groups)
+	if err != nil {
+		return Config{}, err
+	}
 	return config, nil
 }
 
@@ -153,29 +353,92 @@ func (cl ConfigLoader) loadEnvFile(group string, filename string) (map[string]st
 }
 
 type ymlUnitTest struct {
-	Action  string `yaml:"action"`
-	Url     string `yaml:"url"`
-	Status  int    `yaml:"status"`
-	Headers string `yaml:"headers"`
-	In      string `yaml:"in"`
-	Out     string `yaml:"out"`
-	CtIn    string `yaml:"ct_in"`
-	CtOut   string `yaml:"ct_out"`
-	Pcre    string `yaml:"pcre"`
+	Action  string     `yaml:"action"`
+	Url     string     `yaml:"url"`
+	Status  int        `yaml:"status"`
+	Headers ymlHeaders `yaml:"headers"`
+	Query   ymlValues  `yaml:"query"`
+	In      string     `yaml:"in"`
+	Out     string     `yaml:"out"`
+	CtIn    string     `yaml:"ct_in"`
+	CtOut   string     `yaml:"ct_out"`
+	Pcre    string     `yaml:"pcre"`
+
+	// a null value means the header must be absent
+	OutHeaders map[string]*string `yaml:"outHeaders"`
+	// variable name to `Header` or `Header: regexp`
+	CaptureHeaders map[string]string `yaml:"captureHeaders"`
+	// a null value means the cookie must not be set
+	OutCookies   map[string]*CookieAssertion `yaml:"outCookies"`
+	ClearCookies bool                        `yaml:"clearCookies"`
+	OutTls       *TlsAssertion               `yaml:"outTls"`
+	// false, true or the maximum number of redirects
+	FollowRedirects ymlRedirects        `yaml:"followRedirects"`
+	OutRedirects    []RedirectAssertion `yaml:"outRedirects"`
+	Form            *ymlForm            `yaml:"form"`
+	FormUrlencoded  ymlValues           `yaml:"formUrlencoded"`
+	AcceptEncoding  string              `yaml:"acceptEncoding"`
+	OutEncoding     string              `yaml:"outEncoding"`
+	ContentEncoding string              `yaml:"contentEncoding"`
+	Sse             *ymlSse             `yaml:"sse"`
+	Timeout         string              `yaml:"timeout"`
+	Graphql         *ymlGraphql         `yaml:"graphql"`
+	OutErrors       string              `yaml:"outErrors"`
+	GrpcStatus      string              `yaml:"grpcStatus"`
+	Stub            *ymlStubAssertion   `yaml:"stub"`
+	Signature       *SignatureAssertion `yaml:"signature"`
+	Email           *ymlEmailAssertion  `yaml:"email"`
 }
 
-func (yut *ymlUnitTest) toUnitTest(file string) (UnitTest, error) {
+// unitTestEntry is decoded by ymlUnitTest.UnmarshalYAML.
+type unitTestEntry ymlUnitTest
 
-	h, err := parseHeader(yut.Headers)
+// UnmarshalYAML reports the unknown keys of the test, and of its blocks,
+// instead of ignoring them.
+func (yut *ymlUnitTest) UnmarshalYAML(node *yaml.Node) error {
+	data, err := yaml.Marshal(node)
 	if err != nil {
-		return UnitTest{}, err
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err = decoder.Decode((*unitTestEntry)(yut))
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("test at line %d : %w", node.Line, err)
+	}
+	return nil
+}
+
+type ymlRedirects int
+
+func (r *ymlRedirects) UnmarshalYAML(node *yaml.Node) error {
+	var follow bool
+	if node.Decode(&follow) == nil {
+		*r = 0
+		if !follow {
+			*r = -1
+		}
+		return nil
+	}
+	var max int
+	if node.Decode(&max) != nil || max < 0 {
+		return fmt.Errorf("line %d : followRedirects must be true, false or a positive number", node.Line)
+	}
+	*r = ymlRedirects(max)
+	if max == 0 {
+		*r = -1
 	}
+	return nil
+}
+
+func (yut *ymlUnitTest) toUnitTest(file string) (UnitTest, error) {
 	out := UnitTest{
 		File:    file,
 		Action:  yut.Action,
 		Url:     yut.Url,
 		Status:  yut.Status,
-		Headers: h,
+		Headers: map[string][]string(yut.Headers),
+		Query:   map[string][]string(yut.Query),
 		CtIn:    yut.CtIn,
 		CtOut:   yut.CtOut,
 		InName:  yut.In,
@@ -189,13 +452,214 @@ func (yut *ymlUnitTest) toUnitTest(file string) (UnitTest, error) {
 
 	if out.Status == 0 {
 		out.Status = 200
+		if out.Action == "WS_CONNECT" {
+			out.Status = http.StatusSwitchingProtocols
+		}
+	}
+
+	if out.Headers == nil {
+		out.Headers = map[string][]string{}
+	}
+
+	for name, value := range yut.OutHeaders {
+		if value == nil {
+			out.AbsentHeaders = append(out.AbsentHeaders, name)
+			continue
+		}
+		if out.OutHeaders == nil {
+			out.OutHeaders = map[string]string{}
+		}
+		out.OutHeaders[name] = *value
+	}
+	sort.Strings(out.AbsentHeaders)
+
+	for name, spec := range yut.CaptureHeaders {
+		c, err := parseHeaderCapture(spec)
+		if err != nil {
+			return UnitTest{}, fmt.Errorf("in %s, capture of %s : %w", file, name, err)
+		}
+		if out.CaptureHeaders == nil {
+			out.CaptureHeaders = map[string]HeaderCapture{}
+		}
+		out.CaptureHeaders[name] = c
+	}
+
+	for name, cookie := range yut.OutCookies {
+		if cookie == nil {
+			out.AbsentCookies = append(out.AbsentCookies, name)
+			continue
+		}
+		switch strings.ToLower(cookie.SameSite) {
+		case "", "lax", "strict", "none":
+		default:
+			return UnitTest{}, fmt.Errorf("in %s, cookie %s : %w : %s", file, name, ErrInvalidSameSite, cookie.SameSite)
+		}
+		if out.OutCookies == nil {
+			out.OutCookies = map[string]CookieAssertion{}
+		}
+		out.OutCookies[name] = *cookie
+	}
+	sort.Strings(out.AbsentCookies)
+	out.ClearCookies = yut.ClearCookies
+
+	out.MaxRedirects = int(yut.FollowRedirects)
+	out.OutRedirects = yut.OutRedirects
+
+	if yut.OutTls != nil {
+		if _, ok := tlsVersions[yut.OutTls.Version]; !ok && yut.OutTls.Version != "" {
+			return UnitTest{}, fmt.Errorf("in %s : %w : %s", file, ErrInvalidTlsVersion, yut.OutTls.Version)
+		}
+		out.OutTls = yut.OutTls
+	}
+
+	out.AcceptEncoding = yut.AcceptEncoding
+	out.OutEncoding = yut.OutEncoding
+	// the response is only encoded when asked for, identity is asked for too
+	// as the transport would otherwise ask for gzip and hide it
+	if out.AcceptEncoding == "" {
+		out.AcceptEncoding = out.OutEncoding
+	}
+	if yut.ContentEncoding != "" && yut.ContentEncoding != "gzip" {
+		return UnitTest{}, fmt.Errorf("in %s : %w : %s", file, ErrInvalidEncoding, yut.ContentEncoding)
+	}
+	out.ContentEncoding = yut.ContentEncoding
+
+	if yut.GrpcStatus != "" || out.Action == "GRPC" {
+		out.GrpcStatus = yut.GrpcStatus
+		if out.GrpcStatus == "" {
+			out.GrpcStatus = "OK"
+		}
+		if _, ok := grpcCodes[out.GrpcStatus]; !ok || out.Action != "GRPC" {
+			return UnitTest{}, fmt.Errorf("in %s : %w : %s", file, ErrInvalidGrpcStatus, yut.GrpcStatus)
+		}
+	}
+
+	if yut.Timeout != "" {
+		timeout, err := time.ParseDuration(yut.Timeout)
+		if err != nil || timeout <= 0 {
+			return UnitTest{}, fmt.Errorf("in %s : %w : %s", file, ErrInvalidTimeout, yut.Timeout)
+		}
+		out.Timeout = timeout
+	}
+
+	if yut.Stub != nil || out.Action == "STUB" {
+		if yut.Stub == nil || out.Action != "STUB" {
+			return UnitTest{}, fmt.Errorf("in %s : %w", file, ErrInvalidStubStep)
+		}
+		stub, err := yut.Stub.toStubAssertion()
+		if err != nil {
+			return UnitTest{}, fmt.Errorf("in %s : %w", file, err)
+		}
+		out.Stub = stub
+	}
+
+	if yut.Signature != nil {
+		if out.Action != "EXPECT_WEBHOOK" {
+			return UnitTest{}, fmt.Errorf("in %s : %w : only EXPECT_WEBHOOK steps have a signature", file, ErrInvalidSignature)
+		}
+		signature, err := toSignatureAssertion(yut.Signature)
+		if err != nil {
+			return UnitTest{}, fmt.Errorf("in %s : %w", file, err)
+		}
+		out.Signature = signature
+	}
+
+	if yut.Email != nil {
+		if out.Action != "EXPECT_EMAIL" {
+			return UnitTest{}, fmt.Errorf("in %s : %w", file, ErrInvalidEmail)
+		}
+		email, err := yut.Email.toEmailAssertion()
+		if err != nil {
+			return UnitTest{}, fmt.Errorf("in %s : %w", file, err)
+		}
+		out.Email = email
+	}
+
+	if yut.Sse != nil {
+		if yut.Out != "" {
+			return UnitTest{}, fmt.Errorf("in %s : %w", file, ErrSseWithOut)
+		}
+		sse, err := yut.Sse.toSseAssertion()
+		if err != nil {
+			return UnitTest{}, fmt.Errorf("in %s : %w", file, err)
+		}
+		out.Sse = sse
+		if out.CtOut == "" {
+			out.CtOut = "text/event-stream"
+		}
 	}
 
 	return out, nil
 }
 
-func parseHeader(headers string) (map[string]string, error) {
-	out := map[string]string{}
+func parseHeaderCapture(spec string) (HeaderCapture, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	c := HeaderCapture{Header: strings.TrimSpace(parts[0])}
+	if c.Header == "" {
+		return HeaderCapture{}, fmt.Errorf("%w : %s", ErrInvalidCapture, spec)
+	}
+	if len(parts) == 2 {
+		c.Regexp = strings.TrimSpace(parts[1])
+		_, err := regexp.Compile(c.Regexp)
+		if err != nil {
+			return HeaderCapture{}, fmt.Errorf("%w : %v", ErrInvalidCapture, err)
+		}
+	}
+	return c, nil
+}
+
+// ymlValues is a map whose values are a single string or a list of strings.
+type ymlValues map[string][]string
+
+func (v *ymlValues) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d : expected a map", node.Line)
+	}
+	out := ymlValues{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		switch value.Kind {
+		case yaml.ScalarNode:
+			out[key] = append(out[key], value.Value)
+		case yaml.SequenceNode:
+			for _, item := range value.Content {
+				if item.Kind != yaml.ScalarNode {
+					return fmt.Errorf("line %d : values of %s must be strings", item.Line, key)
+				}
+				out[key] = append(out[key], item.Value)
+			}
+		default:
+			return fmt.Errorf("line %d : value of %s must be a string or a list of strings", value.Line, key)
+		}
+	}
+	*v = out
+	return nil
+}
+
+// ymlHeaders accepts a map like ymlValues or the `name: value; name: value`
+// string form.
+type ymlHeaders map[string][]string
+
+func (h *ymlHeaders) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		out, err := parseHeader(node.Value)
+		if err != nil {
+			return err
+		}
+		*h = out
+		return nil
+	}
+	values := ymlValues{}
+	err := values.UnmarshalYAML(node)
+	if err != nil {
+		return err
+	}
+	*h = ymlHeaders(values)
+	return nil
+}
+
+func parseHeader(headers string) (map[string][]string, error) {
+	out := map[string][]string{}
 	headerList := strings.Split(headers, ";")
 
 	for _, h := range headerList {
@@ -206,7 +670,8 @@ func parseHeader(headers string) (map[string]string, error) {
 		if len(part) != 2 {
 			return nil, fmt.Errorf("header `%s must have only on `:`", h)
 		}
-		out[strings.TrimSpace(part[0])] = strings.TrimSpace(part[1])
+		name := strings.TrimSpace(part[0])
+		out[name] = append(out[name], strings.TrimSpace(part[1]))
 	}
 	return out, nil
 }
@@ -229,20 +694,20 @@ func (cl ConfigLoader) loadTests(group string, filenames []string) ([]UnitTest,
 			return nil, nil, fmt.Errorf("while loading %s : %w", filename, err)
 		}
 		config := ymlTestConfig{}
-		err = yaml.Unmarshal([]byte(data), &config)
+		err = yaml.Unmarshal(data, &config)
 		if err != nil {
 			return nil, nil, fmt.Errorf("cannot unmarshal file %s : %w", filename, err)
 		}
 
-		actions := []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+		actions, err := sortActions(config.UnitTests)
+		if err != nil {
+			return nil, nil, fmt.Errorf("in file %s : %w", filename, err)
+		}
 		for _, action := range actions {
-			tests, ok := config.UnitTests[action]
-			if !ok {
-				continue
-			}
-			for _, v := range tests {
+			tests := config.UnitTests[action]
+			for i, v := range tests {
 				v.Action = action
-				u, err := v.toUnitTest(group + "/configs/" + filename + ":" + action)
+				u, err := v.toUnitTest(fmt.Sprintf("%s/configs/%s:%s:%d", group, filename, action, i))
 				if err != nil {
 					return nil, nil, err
 				}
@@ -272,14 +737,42 @@ func (cl ConfigLoader) loadTests(group string, filenames []string) ([]UnitTest,
 	return uts, scenarios, nil
 }
 
+var actionRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9_\-]*$`)
+
+// sortActions keeps the historical order of the common methods, the other ones
+// come next in alphabetical order.
+func sortActions(unitTests map[string][]ymlUnitTest) ([]string, error) {
+	actions := []string{}
+	for _, action := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+		if _, ok := unitTests[action]; ok {
+			actions = append(actions, action)
+		}
+	}
+	others := []string{}
+	for action := range unitTests {
+		if !actionRegexp.MatchString(action) {
+			return nil, fmt.Errorf("%w : %s", ErrUnknownAction, action)
+		}
+		switch action {
+		case "GET", "POST", "PUT", "PATCH", "DELETE":
+		default:
+			others = append(others, action)
+		}
+	}
+	sort.Strings(others)
+	return append(actions, others...), nil
+}
+
 func (cl ConfigLoader) loadTestFile(v ymlUnitTest, u *UnitTest, group string) error {
 	if len(v.In) > 0 && u.Action != "FILE" {
 		ext := getExtension(u.CtIn)
-		in, err := cl.loadFile(group + "/payloads/" + v.In + ext)
+		inFile := group + "/payloads/" + v.In + ext
+		in, err := cl.loadFile(inFile)
 		if err != nil {
 			return err
 		}
 		u.In = in
+		u.InFile = inFile
 	}
 	if len(v.Out) > 0 {
 		ext := getExtension(u.CtOut)
@@ -290,7 +783,23 @@ func (cl ConfigLoader) loadTestFile(v ymlUnitTest, u *UnitTest, group string) er
 		u.Out = out
 	}
 
-	return nil
+	if v.Form != nil && len(v.In) > 0 {
+		return fmt.Errorf("in %s : %w", u.File, ErrFormWithIn)
+	}
+	form, err := cl.loadForm(v.Form, group)
+	if err != nil {
+		return fmt.Errorf("in %s : %w", u.File, err)
+	}
+	u.Form = form
+
+	if v.FormUrlencoded != nil {
+		if len(v.In) > 0 || v.Form != nil {
+			return fmt.Errorf("in %s : %w", u.File, ErrFormUrlencodedWithBody)
+		}
+		u.FormUrlencoded = map[string][]string(v.FormUrlencoded)
+	}
+
+	return cl.loadGraphql(v, u, group)
 }
 
 func getExtension(t string) string {
@@ -1,12 +1,16 @@
 package testerconfig
 
 import (
+	"errors"
 	"fmt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
 	"io"
 	"io/ioutil"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func getTestFileOpener(fs map[string]string) func(string) (io.ReadCloser, error) {
@@ -83,6 +87,7 @@ scenario:
 				"group3/configs/error.yml": `unit_tests:
   GET:
     - { url: "/articles/all", status: 404, out: "notfound" }
+    - { url: "/articles/none", status: 404 }
 `,
 				"group3/responses/allArticles.json":    "1",
 				"group3/payloads/article.json":         "2",
@@ -132,7 +137,7 @@ scenario:
 					},
 					UnitTests: []UnitTest{
 						UnitTest{
-							File:    "group3/configs/access.yml:GET",
+							File:    "group3/configs/access.yml:GET:0",
 							Action:  "GET",
 							Url:     "/articles/all",
 							Status:  200,
@@ -141,52 +146,55 @@ scenario:
 							Out:     []byte("1"),
 							CtOut:   "",
 							OutName: "allArticles",
-							Headers: map[string]string{
-								"Authorization": "Bearer abc",
-								"Test":          "value",
+							Headers: map[string][]string{
+								"Authorization": {"Bearer abc"},
+								"Test":          {"value"},
 							},
 						},
 						UnitTest{
-							File:    "group3/configs/access.yml:POST",
+							File:    "group3/configs/access.yml:POST:0",
 							Action:  "POST",
 							Url:     "/articles",
 							Status:  201,
 							In:      []byte("6"),
 							InName:  "article",
+							InFile:  "group3/payloads/article",
 							CtIn:    "text/plain",
 							Out:     []byte("3"),
 							OutName: "postedArticle",
 							CtOut:   "text/plain",
-							Headers: map[string]string{},
+							Headers: map[string][]string{},
 						},
 						UnitTest{
-							File:    "group3/configs/access.yml:PUT",
+							File:    "group3/configs/access.yml:PUT:0",
 							Action:  "PUT",
 							Url:     "/articles/1",
 							Status:  200,
 							In:      []byte("2"),
 							InName:  "article",
+							InFile:  "group3/payloads/article.json",
 							CtIn:    "application/json",
 							Out:     []byte("4"),
 							OutName: "updatedArticle",
 							CtOut:   "",
-							Headers: map[string]string{},
+							Headers: map[string][]string{},
 						},
 						UnitTest{
-							File:    "group3/configs/access.yml:PATCH",
+							File:    "group3/configs/access.yml:PATCH:0",
 							Action:  "PATCH",
 							Url:     "/articles/1",
 							Status:  200,
 							In:      []byte("2"),
 							InName:  "article",
+							InFile:  "group3/payloads/article.json",
 							CtIn:    "application/json",
 							Out:     []byte("4"),
 							OutName: "updatedArticle",
 							CtOut:   "",
-							Headers: map[string]string{},
+							Headers: map[string][]string{},
 						},
 						UnitTest{
-							File:    "group3/configs/access.yml:DELETE",
+							File:    "group3/configs/access.yml:DELETE:0",
 							Action:  "DELETE",
 							Url:     "/articles/1",
 							Status:  204,
@@ -194,10 +202,10 @@ scenario:
 							CtIn:    "application/json",
 							Out:     nil,
 							CtOut:   "",
-							Headers: map[string]string{},
+							Headers: map[string][]string{},
 						},
 						UnitTest{
-							File:    "group3/configs/error.yml:GET",
+							File:    "group3/configs/error.yml:GET:0",
 							Action:  "GET",
 							Url:     "/articles/all",
 							Status:  404,
@@ -206,7 +214,18 @@ scenario:
 							Out:     []byte("7"),
 							OutName: "notfound",
 							CtOut:   "",
-							Headers: map[string]string{},
+							Headers: map[string][]string{},
+						},
+						UnitTest{
+							File:    "group3/configs/error.yml:GET:1",
+							Action:  "GET",
+							Url:     "/articles/none",
+							Status:  404,
+							In:      nil,
+							CtIn:    "application/json",
+							Out:     nil,
+							CtOut:   "",
+							Headers: map[string][]string{},
 						},
 					},
 					ScenarioOrder: []string{
@@ -222,10 +241,11 @@ scenario:
 								Status:  201,
 								In:      []byte("5"),
 								InName:  "postArticle",
+								InFile:  "group3/payloads/postArticle.json",
 								CtIn:    "application/json",
 								Out:     nil,
 								CtOut:   "",
-								Headers: map[string]string{},
+								Headers: map[string][]string{},
 							},
 							UnitTest{
 								File:    "group3/configs/access.yml:createAndDeleteArticle:DELETE:1",
@@ -236,7 +256,7 @@ scenario:
 								CtIn:    "application/json",
 								Out:     nil,
 								CtOut:   "",
-								Headers: map[string]string{},
+								Headers: map[string][]string{},
 							},
 						},
 						"group3/configs/access.yml:addArticle": []UnitTest{
@@ -247,10 +267,11 @@ scenario:
 								Status:  201,
 								In:      []byte("5"),
 								InName:  "postArticle",
+								InFile:  "group3/payloads/postArticle.json",
 								CtIn:    "application/json",
 								Out:     nil,
 								CtOut:   "",
-								Headers: map[string]string{},
+								Headers: map[string][]string{},
 							},
 						},
 					},
@@ -281,3 +302,807 @@ scenario:
 		}
 	}
 }
+
+func TestLoadActions(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml": `url: https://localhost:8000
+groups:
+  group1:
+    tests:
+      - methods.yml`,
+		"group1/configs/methods.yml": `unit_tests:
+  PURGE:
+    - { url: "/cache" }
+  OPTIONS:
+    - { url: "/articles", status: 204, headers: "Origin : localhost" }
+  HEAD:
+    - { url: "/articles/1" }
+  GET:
+    - { url: "/articles/1" }
+`,
+	}
+	expected := []UnitTest{
+		{File: "group1/configs/methods.yml:GET:0", Action: "GET", Url: "/articles/1", Status: 200, CtIn: "application/json", Headers: map[string][]string{}},
+		{File: "group1/configs/methods.yml:HEAD:0", Action: "HEAD", Url: "/articles/1", Status: 200, CtIn: "application/json", Headers: map[string][]string{}},
+		{File: "group1/configs/methods.yml:OPTIONS:0", Action: "OPTIONS", Url: "/articles", Status: 204, CtIn: "application/json", Headers: map[string][]string{"Origin": {"localhost"}}},
+		{File: "group1/configs/methods.yml:PURGE:0", Action: "PURGE", Url: "/cache", Status: 200, CtIn: "application/json", Headers: map[string][]string{}},
+	}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if !reflect.DeepEqual(result.Groups["group1"].UnitTests, expected) {
+		t.Fatalf("failed \n exp %#v \n got %#v", expected, result.Groups["group1"].UnitTests)
+	}
+}
+
+func TestLoadHeadersAndQuery(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml": `url: https://localhost:8000
+groups:
+  group1:
+    tests:
+      - headers.yml`,
+		"group1/configs/headers.yml": `unit_tests:
+  GET:
+    - { url: "/articles", headers: { Authorization: "Bearer #token#", Accept: [ "application/json", "text/plain" ], X-Date: "12:00" }, query: { limit: 10, tag: [ "a", "b" ] } }
+    - { url: "/articles", headers: "Authorization : Bearer #token#; Accept : text/plain", outHeaders: { Location: "/articles/@number@", X-Powered-By: ~, Server: ~ }, captureHeaders: { etag: ETag, id: "Location: /articles/([0-9]+)" } }
+`,
+	}
+	expected := []UnitTest{
+		{
+			File: "group1/configs/headers.yml:GET:0", Action: "GET", Url: "/articles", Status: 200, CtIn: "application/json",
+			Headers: map[string][]string{"Authorization": {"Bearer #token#"}, "Accept": {"application/json", "text/plain"}, "X-Date": {"12:00"}},
+			Query:   map[string][]string{"limit": {"10"}, "tag": {"a", "b"}},
+		},
+		{
+			File: "group1/configs/headers.yml:GET:1", Action: "GET", Url: "/articles", Status: 200, CtIn: "application/json",
+			Headers:       map[string][]string{"Authorization": {"Bearer #token#"}, "Accept": {"text/plain"}},
+			OutHeaders:    map[string]string{"Location": "/articles/@number@"},
+			AbsentHeaders: []string{"Server", "X-Powered-By"},
+			CaptureHeaders: map[string]HeaderCapture{
+				"etag": {Header: "ETag"},
+				"id":   {Header: "Location", Regexp: "/articles/([0-9]+)"},
+			},
+		},
+	}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if !reflect.DeepEqual(result.Groups["group1"].UnitTests, expected) {
+		t.Fatalf("failed \n exp %#v \n got %#v", expected, result.Groups["group1"].UnitTests)
+	}
+
+	filesystem["group1/configs/headers.yml"] = "unit_tests:\n  GET:\n    - { url: \"/articles\", query: { tag: { a: b } } }\n"
+	loader.fileOpener = getTestFileOpener(filesystem)
+	_, err = loader.Load("conf.yml")
+	if err == nil {
+		t.Fatalf("failed a query value must be a string or a list")
+	}
+
+	filesystem["group1/configs/headers.yml"] = "unit_tests:\n  GET:\n    - { url: \"/articles\", captureHeaders: { id: \"Location: /articles/([0-9]+\" } }\n"
+	loader.fileOpener = getTestFileOpener(filesystem)
+	_, err = loader.Load("conf.yml")
+	if !errors.Is(err, ErrInvalidCapture) {
+		t.Fatalf("failed got %v, exp %v", err, ErrInvalidCapture)
+	}
+}
+
+func TestLoadCookies(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml": `url: https://localhost:8000
+cookies: true
+groups:
+  group1:
+    tests:
+      - cookies.yml
+  group2:
+    cookies: false
+    tests:
+      - cookies.yml`,
+		"group1/configs/cookies.yml": `scenario:
+  login:
+    - { action: POST, url: "/login", outCookies: { session: { value: "@string@", httpOnly: true, sameSite: Lax, expires: session }, remember: ~ } }
+    - { action: POST, url: "/logout", clearCookies: true }
+`,
+		"group2/configs/cookies.yml": `unit_tests:
+  GET:
+    - { url: "/articles" }
+`,
+	}
+	yes := true
+	expected := []UnitTest{
+		{
+			File: "group1/configs/cookies.yml:login:POST:0", Action: "POST", Url: "/login", Status: 200, CtIn: "application/json", Headers: map[string][]string{},
+			OutCookies:    map[string]CookieAssertion{"session": {Value: "@string@", HttpOnly: &yes, SameSite: "Lax", Expires: "session"}},
+			AbsentCookies: []string{"remember"},
+		},
+		{
+			File: "group1/configs/cookies.yml:login:POST:1", Action: "POST", Url: "/logout", Status: 200, CtIn: "application/json", Headers: map[string][]string{},
+			ClearCookies: true,
+		},
+	}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if !result.Groups["group1"].Cookies || result.Groups["group2"].Cookies {
+		t.Fatalf("failed cookies got %t %t", result.Groups["group1"].Cookies, result.Groups["group2"].Cookies)
+	}
+	if !reflect.DeepEqual(result.Groups["group1"].Scenarios["group1/configs/cookies.yml:login"], expected) {
+		t.Fatalf("failed \n exp %#v \n got %#v", expected, result.Groups["group1"].Scenarios["group1/configs/cookies.yml:login"])
+	}
+
+	filesystem["group2/configs/cookies.yml"] = "unit_tests:\n  GET:\n    - { url: \"/\", outCookies: { session: { sameSite: Relaxed } } }\n"
+	loader.fileOpener = getTestFileOpener(filesystem)
+	_, err = loader.Load("conf.yml")
+	if !errors.Is(err, ErrInvalidSameSite) {
+		t.Fatalf("failed got %v, exp %v", err, ErrInvalidSameSite)
+	}
+}
+
+func TestLoadHttp(t *testing.T) {
+	tests := []struct {
+		conf     string
+		expected HttpConfig
+	}{
+		{
+			conf:     "url: https://localhost:8000\ngroups: {}",
+			expected: HttpConfig{KeepAlive: true, Http2: true},
+		},
+		{
+			conf:     "url: https://localhost:8000\nhttp:\n  maxIdleConnections: 10\n  keepAlive: false\n  http2: false\ngroups: {}",
+			expected: HttpConfig{MaxIdleConnections: 10},
+		},
+	}
+
+	for i, tt := range tests {
+		loader := New()
+		loader.fileOpener = getTestFileOpener(map[string]string{"conf.yml": tt.conf})
+		result, err := loader.Load("conf.yml")
+		if err != nil {
+			t.Fatalf("%d failed %v", i, err)
+		}
+		if result.Http != tt.expected {
+			t.Fatalf("%d failed \n exp %#v \n got %#v", i, tt.expected, result.Http)
+		}
+	}
+}
+
+func TestLoadRedirects(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml": "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+		"group1/configs/tests.yml": `unit_tests:
+  GET:
+    - { url: "/login", status: 302, followRedirects: false }
+    - { url: "/login", followRedirects: true, outRedirects: [ { status: 302, location: "/home" }, { location: "/@string@" } ] }
+    - { url: "/login", followRedirects: 3 }
+    - { url: "/login", followRedirects: 0 }
+    - { url: "/login" }
+`,
+	}
+	expected := []struct {
+		maxRedirects int
+		outRedirects []RedirectAssertion
+	}{
+		{-1, nil},
+		{0, []RedirectAssertion{{Status: 302, Location: "/home"}, {Location: "/@string@"}}},
+		{3, nil},
+		{-1, nil},
+		{0, nil},
+	}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	for i, ut := range result.Groups["group1"].UnitTests {
+		if ut.MaxRedirects != expected[i].maxRedirects || !reflect.DeepEqual(ut.OutRedirects, expected[i].outRedirects) {
+			t.Fatalf("%d failed got %d %v", i, ut.MaxRedirects, ut.OutRedirects)
+		}
+	}
+
+	filesystem["group1/configs/tests.yml"] = "unit_tests:\n  GET:\n    - { url: \"/\", followRedirects: always }\n"
+	_, err = loader.Load("conf.yml")
+	if err == nil {
+		t.Fatalf("failed followRedirects must be a bool or a number")
+	}
+}
+
+func TestLoadForm(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml": "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+		"group1/configs/tests.yml": `unit_tests:
+  POST:
+    - { url: "/upload", form: { fields: { title: "#title#", tags: [ a, b ] }, files: [ { name: doc, path: "docs/file.pdf" }, { name: raw, path: "data", filename: "data.bin", contentType: "application/x-raw" } ] } }
+`,
+		"group1/payloads/docs/file.pdf": "%PDF",
+		"group1/payloads/data":          "raw",
+	}
+	expected := &Form{
+		Fields: map[string][]string{"title": {"#title#"}, "tags": {"a", "b"}},
+		Files: []FormFile{
+			{Name: "doc", Filename: "file.pdf", ContentType: "application/pdf", Path: "group1/payloads/docs/file.pdf", Content: []byte("%PDF")},
+			{Name: "raw", Filename: "data.bin", ContentType: "application/x-raw", Path: "group1/payloads/data", Content: []byte("raw")},
+		},
+	}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if !reflect.DeepEqual(result.Groups["group1"].UnitTests[0].Form, expected) {
+		t.Fatalf("failed \n exp %#v \n got %#v", expected, result.Groups["group1"].UnitTests[0].Form)
+	}
+
+	errorTests := []struct {
+		tests    string
+		expected error
+	}{
+		{"unit_tests:\n  POST:\n    - { url: \"/\", in: data, ct_in: text/plain, form: { fields: { a: b } } }\n", ErrFormWithIn},
+		{"unit_tests:\n  POST:\n    - { url: \"/\", form: { files: [ { path: data } ] } }\n", ErrFormFile},
+		{"unit_tests:\n  POST:\n    - { url: \"/\", form: { files: [ { name: doc, path: missing } ] } }\n", nil},
+	}
+	for i, tt := range errorTests {
+		filesystem["group1/configs/tests.yml"] = tt.tests
+		_, err = loader.Load("conf.yml")
+		if err == nil || (tt.expected != nil && !errors.Is(err, tt.expected)) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+}
+
+func TestLoadFormUrlencoded(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml": "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+		"group1/configs/tests.yml": `unit_tests:
+  POST:
+    - { url: "/token", formUrlencoded: { grant_type: password, scope: [ read, write ], username: "#user#" } }
+`,
+		"group1/payloads/data": "raw",
+	}
+	expected := map[string][]string{"grant_type": {"password"}, "scope": {"read", "write"}, "username": {"#user#"}}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if !reflect.DeepEqual(result.Groups["group1"].UnitTests[0].FormUrlencoded, expected) {
+		t.Fatalf("failed \n exp %#v \n got %#v", expected, result.Groups["group1"].UnitTests[0].FormUrlencoded)
+	}
+
+	errorTests := []string{
+		"unit_tests:\n  POST:\n    - { url: \"/\", in: data, ct_in: text/plain, formUrlencoded: { a: b } }\n",
+		"unit_tests:\n  POST:\n    - { url: \"/\", form: { fields: { a: b } }, formUrlencoded: { a: b } }\n",
+	}
+	for i, tests := range errorTests {
+		filesystem["group1/configs/tests.yml"] = tests
+		_, err = loader.Load("conf.yml")
+		if !errors.Is(err, ErrFormUrlencodedWithBody) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, ErrFormUrlencodedWithBody)
+		}
+	}
+}
+
+func TestLoadEncoding(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml": "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+		"group1/configs/tests.yml": `unit_tests:
+  GET:
+    - { url: "/articles", outEncoding: gzip }
+    - { url: "/articles", acceptEncoding: "br, gzip", outEncoding: br }
+    - { url: "/articles", outEncoding: identity }
+  POST:
+    - { url: "/articles", contentEncoding: gzip }
+`,
+	}
+	expected := [][3]string{
+		{"gzip", "gzip", ""},
+		{"br, gzip", "br", ""},
+		{"identity", "identity", ""},
+		{"", "", "gzip"},
+	}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	for i, ut := range result.Groups["group1"].UnitTests {
+		got := [3]string{ut.AcceptEncoding, ut.OutEncoding, ut.ContentEncoding}
+		if got != expected[i] {
+			t.Fatalf("%d failed got %v exp %v", i, got, expected[i])
+		}
+	}
+
+	filesystem["group1/configs/tests.yml"] = "unit_tests:\n  POST:\n    - { url: \"/\", contentEncoding: br }\n"
+	_, err = loader.Load("conf.yml")
+	if !errors.Is(err, ErrInvalidEncoding) {
+		t.Fatalf("failed got %v, exp %v", err, ErrInvalidEncoding)
+	}
+}
+
+func TestLoadSse(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml": "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+		"group1/configs/tests.yml": `unit_tests:
+  GET:
+    - { url: "/events", sse: { count: 3, timeout: 2s, events: [ { event: created, id: "@integer@", data: { id: "#id={{@integer@}}", tags: [ a ] } }, { data: ping } ] } }
+    - { url: "/events", ct_out: "text/event-stream; charset=utf-8", sse: { events: [ { event: created } ] } }
+`,
+	}
+	expected := []*SseAssertion{
+		{Count: 3, Timeout: 2 * time.Second, Events: []SseEvent{
+			{Event: "created", Id: "@integer@", Data: map[string]interface{}{"id": "#id={{@integer@}}", "tags": []interface{}{"a"}}},
+			{Data: "ping"},
+		}},
+		{Count: 1, Timeout: 10 * time.Second, Events: []SseEvent{{Event: "created"}}},
+	}
+	expectedCtOut := []string{"text/event-stream", "text/event-stream; charset=utf-8"}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	for i, ut := range result.Groups["group1"].UnitTests {
+		if !reflect.DeepEqual(ut.Sse, expected[i]) {
+			t.Fatalf("%d failed \n exp %#v \n got %#v", i, expected[i], ut.Sse)
+		}
+		if ut.CtOut != expectedCtOut[i] {
+			t.Fatalf("%d failed got %s exp %s", i, ut.CtOut, expectedCtOut[i])
+		}
+	}
+
+	errorTests := []struct {
+		tests    string
+		expected error
+	}{
+		{"unit_tests:\n  GET:\n    - { url: \"/\", sse: { count: 1, events: [ { data: a }, { data: b } ] } }\n", ErrInvalidSse},
+		{"unit_tests:\n  GET:\n    - { url: \"/\", sse: { timeout: 2, events: [ { data: a } ] } }\n", ErrInvalidSse},
+		{"unit_tests:\n  GET:\n    - { url: \"/\", out: events, sse: { events: [ { data: a } ] } }\n", ErrSseWithOut},
+	}
+	for i, tt := range errorTests {
+		filesystem["group1/configs/tests.yml"] = tt.tests
+		_, err = loader.Load("conf.yml")
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+}
+
+func TestLoadWebSocket(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml": "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+		"group1/configs/tests.yml": `scenario:
+  notified:
+    - { action: "WS_CONNECT", url: "/ws", headers: { Authorization: "Bearer #token#" } }
+    - { action: "WS_SEND", in: "subscribe" }
+    - { action: "WS_EXPECT", out: "created", timeout: 2s }
+    - { action: "WS_CLOSE" }
+`,
+		"group1/payloads/subscribe.json": `{"topic":"articles"}`,
+		"group1/responses/created.json":  `{"id":"@integer@"}`,
+	}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	steps := result.Groups["group1"].Scenarios["group1/configs/tests.yml:notified"]
+	if len(steps) != 4 {
+		t.Fatalf("failed got %#v", steps)
+	}
+	if steps[0].Status != 101 || steps[0].Replayable() {
+		t.Fatalf("failed got %#v", steps[0])
+	}
+	if string(steps[1].In) != `{"topic":"articles"}` {
+		t.Fatalf("failed got %s", steps[1].In)
+	}
+	if string(steps[2].Out) != `{"id":"@integer@"}` || steps[2].Timeout != 2*time.Second {
+		t.Fatalf("failed got %#v", steps[2])
+	}
+
+	filesystem["group1/configs/tests.yml"] = "scenario:\n  s:\n    - { action: \"WS_EXPECT\", timeout: 2 }\n"
+	_, err = loader.Load("conf.yml")
+	if !errors.Is(err, ErrInvalidTimeout) {
+		t.Fatalf("failed got %v, exp %v", err, ErrInvalidTimeout)
+	}
+}
+
+func TestLoadGraphql(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml": "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+		"group1/configs/tests.yml": `unit_tests:
+  GRAPHQL:
+    - { url: "/graphql", graphql: { query: "article", variables: { id: "#id#", limit: 2 }, operationName: "Article" }, out: "article" }
+    - { url: "/graphql", graphql: { query: "article", variables: "article_vars" }, outErrors: "not_found" }
+    - { url: "/graphql", graphql: { query: "article" } }
+    - { url: "/graphql", graphql: { query: "article", variables: "article_num_vars" } }
+`,
+		"group1/payloads/article.graphql":       "query Article($id: ID!) { article(id: $id) { title } }\n",
+		"group1/payloads/article_vars.json":     `{"id":"#id#"}`,
+		"group1/payloads/article_num_vars.json": "{ \"id\": #id# }\n",
+		"group1/responses/article.json":         `{"article":{"title":"a"}}`,
+		"group1/responses/not_found.json":       `[{"message":"@string@"}]`,
+	}
+	expectedIn := []string{
+		`{"query":"query Article($id: ID!) { article(id: $id) { title } }\n","variables":{"id":"#id#","limit":2},"operationName":"Article"}`,
+		`{"query":"query Article($id: ID!) { article(id: $id) { title } }\n","variables":{"id":"#id#"}}`,
+		`{"query":"query Article($id: ID!) { article(id: $id) { title } }\n"}`,
+		`{"query":"query Article($id: ID!) { article(id: $id) { title } }\n","variables":{ "id": #id# }}`,
+	}
+	expectedOutErrors := []string{"", `[{"message":"@string@"}]`, "", ""}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	for i, ut := range result.Groups["group1"].UnitTests {
+		if string(ut.In) != expectedIn[i] {
+			t.Fatalf("%d failed got %s exp %s", i, ut.In, expectedIn[i])
+		}
+		if string(ut.OutErrors) != expectedOutErrors[i] {
+			t.Fatalf("%d failed got %s exp %s", i, ut.OutErrors, expectedOutErrors[i])
+		}
+		if ut.Method() != "POST" || ut.CtIn != "application/json" {
+			t.Fatalf("%d failed got %s %s", i, ut.Method(), ut.CtIn)
+		}
+	}
+	if string(result.Groups["group1"].UnitTests[0].Out) != `{"article":{"title":"a"}}` {
+		t.Fatalf("failed got %s", result.Groups["group1"].UnitTests[0].Out)
+	}
+
+	errorTests := []string{
+		"unit_tests:\n  GRAPHQL:\n    - { url: \"/graphql\" }\n",
+		"unit_tests:\n  GRAPHQL:\n    - { url: \"/graphql\", in: article_vars, graphql: { query: article } }\n",
+		"unit_tests:\n  POST:\n    - { url: \"/graphql\", graphql: { query: article } }\n",
+	}
+	for i, tests := range errorTests {
+		filesystem["group1/configs/tests.yml"] = tests
+		_, err = loader.Load("conf.yml")
+		if !errors.Is(err, ErrInvalidGraphql) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, ErrInvalidGraphql)
+		}
+	}
+}
+
+func TestLoadGrpc(t *testing.T) {
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:        proto.String("articles.proto"),
+		Package:     proto.String("articles.v1"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Article")}},
+	}}}
+	descriptors, _ := proto.Marshal(set)
+	filesystem := map[string]string{
+		"conf.yml": "url: https://localhost:8000\ngrpc: { descriptors: api.pb }\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+		"api.pb":   string(descriptors),
+		"group1/configs/tests.yml": `unit_tests:
+  GRPC:
+    - { url: "articles.v1.Articles/Get", in: "article" }
+    - { url: "articles.v1.Articles/Get", in: "article", grpcStatus: NOT_FOUND, timeout: 1s }
+`,
+		"group1/payloads/article.json": `{"id":"#id#"}`,
+	}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if result.Grpc.Address != "localhost:8000" || result.Grpc.Plaintext || result.Grpc.DescriptorsFile != "api.pb" {
+		t.Fatalf("failed got %#v", result.Grpc)
+	}
+	if _, err := result.Grpc.Descriptors.FindDescriptorByName("articles.v1.Article"); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	uts := result.Groups["group1"].UnitTests
+	if uts[0].GrpcStatus != "OK" || uts[1].GrpcStatus != "NOT_FOUND" || uts[1].Timeout != time.Second || uts[0].Replayable() {
+		t.Fatalf("failed got %#v", uts)
+	}
+
+	filesystem["conf.yml"] = "url: https://localhost:8000\ngrpc: { address: \"localhost:9090\", descriptors: api.pb, plaintext: true }\ngroups:\n  group1:\n    tests:\n      - tests.yml"
+	result, err = loader.Load("conf.yml")
+	if err != nil || result.Grpc.Address != "localhost:9090" || !result.Grpc.Plaintext {
+		t.Fatalf("failed got %#v, %v", result.Grpc, err)
+	}
+
+	errorTests := []struct {
+		conf     string
+		tests    string
+		expected error
+	}{
+		{"url: https://localhost:8000\ngrpc: { address: \"localhost:9090\" }\ngroups:\n  group1:\n    tests:\n      - tests.yml", "", ErrInvalidGrpc},
+		{"url: https://localhost:8000\ngrpc: { descriptors: article.json }\ngroups:\n  group1:\n    tests:\n      - tests.yml", "", ErrInvalidGrpc},
+		{"url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml", "unit_tests:\n  GRPC:\n    - { url: \"a.B/C\", grpcStatus: NotFound }\n", ErrInvalidGrpcStatus},
+		{"url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml", "unit_tests:\n  GET:\n    - { url: \"/\", grpcStatus: OK }\n", ErrInvalidGrpcStatus},
+	}
+	filesystem["article.json"] = "{}"
+	for i, tt := range errorTests {
+		filesystem["conf.yml"] = tt.conf
+		filesystem["group1/configs/tests.yml"] = tt.tests
+		_, err = loader.Load("conf.yml")
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+}
+
+func TestLoadStubs(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml": `url: https://localhost:8000
+groups:
+  group1:
+    tests:
+      - tests.yml
+    stubs:
+      payments:
+        port: 9100
+        routes:
+          - { method: post, url: /charges, status: 201, out: charge, headers: { X-Stub: payments }, latency: 200ms }
+          - { method: GET, url: "/charges?failed=1", error: close }
+      mails: {}
+`,
+		"group1/configs/tests.yml": `scenario:
+  paid:
+    - { action: POST, url: "/orders", status: 201 }
+    - { action: STUB, stub: { name: payments, method: post, url: /charges, calls: 1, bodies: [ { amount: "@integer@" } ] } }
+    - { action: STUB, stub: { name: mails } }
+`,
+		"group1/responses/charge.json": `{"id":"ch_1"}`,
+	}
+	expectedStubs := []Stub{
+		{Name: "mails", Routes: []StubRoute{}},
+		{Name: "payments", Port: 9100, Routes: []StubRoute{
+			{Method: "POST", Url: "/charges", Status: 201, CtOut: "application/json", Headers: map[string][]string{"X-Stub": {"payments"}}, Out: []byte(`{"id":"ch_1"}`), Latency: 200 * time.Millisecond},
+			{Method: "GET", Url: "/charges?failed=1", Status: 200, Headers: map[string][]string{}, Error: "close"},
+		}},
+	}
+	expectedSteps := []*StubAssertion{
+		nil,
+		{Name: "payments", Method: "POST", Url: "/charges", Calls: 1, Bodies: []interface{}{map[string]interface{}{"amount": "@integer@"}}},
+		{Name: "mails", Calls: -1, Bodies: []interface{}{}},
+	}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if !reflect.DeepEqual(result.Groups["group1"].Stubs, expectedStubs) {
+		t.Fatalf("failed \n exp %#v \n got %#v", expectedStubs, result.Groups["group1"].Stubs)
+	}
+	for i, step := range result.Groups["group1"].Scenarios["group1/configs/tests.yml:paid"] {
+		if !reflect.DeepEqual(step.Stub, expectedSteps[i]) {
+			t.Fatalf("%d failed \n exp %#v \n got %#v", i, expectedSteps[i], step.Stub)
+		}
+		if i > 0 && step.Replayable() {
+			t.Fatalf("%d failed, a STUB step can't be replayed", i)
+		}
+	}
+
+	errorTests := []struct {
+		stubs    string
+		tests    string
+		expected error
+	}{
+		{"{ routes: [ { url: /charges } ] }", "", ErrInvalidStub},
+		{"{ routes: [ { method: GET, url: /charges, error: reset } ] }", "", ErrInvalidStub},
+		{"{ routes: [ { method: GET, url: /charges, latency: 2 } ] }", "", ErrInvalidStub},
+		{"{ port: 70000 }", "", ErrInvalidStub},
+		{"{}", "unit_tests:\n  STUB:\n    - { url: \"/\" }\n", ErrInvalidStubStep},
+		{"{}", "unit_tests:\n  GET:\n    - { url: \"/\", stub: { name: payments } }\n", ErrInvalidStubStep},
+		{"{}", "unit_tests:\n  STUB:\n    - { stub: { name: mails } }\n", ErrInvalidStubStep},
+		{"{}", "unit_tests:\n  STUB:\n    - { stub: { name: payments, calls: -1 } }\n", ErrInvalidStubStep},
+	}
+	for i, tt := range errorTests {
+		filesystem["conf.yml"] = "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml\n    stubs:\n      payments: " + tt.stubs + "\n"
+		filesystem["group1/configs/tests.yml"] = tt.tests
+		_, err = loader.Load("conf.yml")
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+}
+
+func TestLoadWebhooks(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml": "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+		"group1/configs/tests.yml": `scenario:
+  paid:
+    - { action: POST, url: "/orders", status: 201 }
+    - { action: EXPECT_WEBHOOK, url: /orders, timeout: 5s, out: paid, outHeaders: { X-Event: order.paid }, signature: { header: X-Signature, secret: "#secret#", prefix: "sha256=" } }
+    - { action: EXPECT_WEBHOOK, signature: { header: X-Signature, secret: abc, algorithm: sha1, encoding: base64 } }
+`,
+		"group1/responses/paid.json": `{"order":"@string@"}`,
+	}
+	expected := []*SignatureAssertion{
+		nil,
+		{Header: "X-Signature", Secret: "#secret#", Algorithm: "sha256", Encoding: "hex", Prefix: "sha256="},
+		{Header: "X-Signature", Secret: "abc", Algorithm: "sha1", Encoding: "base64"},
+	}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if !reflect.DeepEqual(result.Webhooks, &WebhookConfig{}) {
+		t.Fatalf("the receiver must be started for EXPECT_WEBHOOK, got %#v", result.Webhooks)
+	}
+	steps := result.Groups["group1"].Scenarios["group1/configs/tests.yml:paid"]
+	for i, step := range steps {
+		if !reflect.DeepEqual(step.Signature, expected[i]) {
+			t.Fatalf("%d failed \n exp %#v \n got %#v", i, expected[i], step.Signature)
+		}
+	}
+	if string(steps[1].Out) != `{"order":"@string@"}` || steps[1].Timeout != 5*time.Second || steps[1].Replayable() {
+		t.Fatalf("failed got %#v", steps[1])
+	}
+
+	filesystem["conf.yml"] = "url: https://localhost:8000\nwebhooks: { port: 9200 }\ngroups:\n  group1:\n    tests:\n      - tests.yml"
+	filesystem["group1/configs/tests.yml"] = ""
+	result, err = loader.Load("conf.yml")
+	if err != nil || !reflect.DeepEqual(result.Webhooks, &WebhookConfig{Port: 9200}) {
+		t.Fatalf("failed got %#v %v", result.Webhooks, err)
+	}
+	filesystem["conf.yml"] = "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml"
+	result, err = loader.Load("conf.yml")
+	if err != nil || result.Webhooks != nil {
+		t.Fatalf("failed got %#v %v", result.Webhooks, err)
+	}
+
+	errorTests := []struct {
+		tests    string
+		expected error
+	}{
+		{"unit_tests:\n  EXPECT_WEBHOOK:\n    - { signature: { header: X-Signature } }\n", ErrInvalidSignature},
+		{"unit_tests:\n  EXPECT_WEBHOOK:\n    - { signature: { header: X-Signature, secret: abc, algorithm: md5 } }\n", ErrInvalidSignature},
+		{"unit_tests:\n  EXPECT_WEBHOOK:\n    - { signature: { header: X-Signature, secret: abc, encoding: base32 } }\n", ErrInvalidSignature},
+		{"unit_tests:\n  POST:\n    - { url: /, signature: { header: X-Signature, secret: abc } }\n", ErrInvalidSignature},
+	}
+	for i, tt := range errorTests {
+		filesystem["group1/configs/tests.yml"] = tt.tests
+		_, err = loader.Load("conf.yml")
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+	filesystem["conf.yml"] = "url: https://localhost:8000\nwebhooks: { port: -1 }\ngroups: {}"
+	_, err = loader.Load("conf.yml")
+	if !errors.Is(err, ErrInvalidWebhook) {
+		t.Fatalf("failed got %v", err)
+	}
+}
+
+func TestLoadSmtp(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml": "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml\n  group2:\n    smtp: { port: 2525 }\n  group3: {}",
+		"group1/configs/tests.yml": `scenario:
+  reset:
+    - { action: POST, url: "/password/reset", status: 202 }
+    - { action: EXPECT_EMAIL, timeout: 5s, email: { to: "#email#", subject: "@string@.contains('Reset')", capture: { token: "token=([a-f0-9]+)" } } }
+    - { action: EXPECT_EMAIL }
+`,
+	}
+
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if !reflect.DeepEqual(result.Groups["group1"].Smtp, &SmtpConfig{}) {
+		t.Fatalf("the server must be started for EXPECT_EMAIL, got %#v", result.Groups["group1"].Smtp)
+	}
+	if !reflect.DeepEqual(result.Groups["group2"].Smtp, &SmtpConfig{Port: 2525}) || result.Groups["group3"].Smtp != nil {
+		t.Fatalf("failed got %#v %#v", result.Groups["group2"].Smtp, result.Groups["group3"].Smtp)
+	}
+	steps := result.Groups["group1"].Scenarios["group1/configs/tests.yml:reset"]
+	expected := &EmailAssertion{To: "#email#", Subject: "@string@.contains('Reset')", Capture: map[string]string{"token": "token=([a-f0-9]+)"}}
+	if !reflect.DeepEqual(steps[1].Email, expected) || steps[1].Timeout != 5*time.Second || steps[1].Replayable() {
+		t.Fatalf("failed got %#v", steps[1])
+	}
+	if steps[2].Email != nil {
+		t.Fatalf("failed got %#v", steps[2].Email)
+	}
+
+	errorTests := []struct {
+		conf     string
+		tests    string
+		expected error
+	}{
+		{"", "unit_tests:\n  EXPECT_EMAIL:\n    - { email: { capture: { token: \"token=(\" } } }\n", ErrInvalidEmail},
+		{"", "unit_tests:\n  GET:\n    - { url: /, email: { to: a@b.c } }\n", ErrInvalidEmail},
+		{"\n    smtp: { port: 70000 }", "", ErrInvalidSmtp},
+	}
+	for i, tt := range errorTests {
+		filesystem["conf.yml"] = "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml" + tt.conf
+		filesystem["group1/configs/tests.yml"] = tt.tests
+		_, err = loader.Load("conf.yml")
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+}
+
+func TestLoadUnknownKeys(t *testing.T) {
+	tests := []struct {
+		conf     string
+		tests    string
+		expected error
+	}{
+		{
+			conf:     "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+			tests:    "unit_tests:\n  get:\n    - { url: \"/articles\" }\n",
+			expected: ErrUnknownAction,
+		},
+		{
+			conf:  "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+			tests: "unit_tests:\n  GET:\n    - { url: \"/articles\", stauts: 200 }\n",
+		},
+		{
+			conf:  "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+			tests: "scenario:\n  s1:\n    - { action: GET, url: \"/articles\", ct_ot: \"text/plain\" }\n",
+		},
+		{
+			conf:  "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+			tests: "unit_tests:\n  GET:\n    - { url: \"/articles\", sse: { count: 1, timout: 1s } }\n",
+		},
+	}
+
+	for i, tt := range tests {
+		loader := New()
+		loader.fileOpener = getTestFileOpener(map[string]string{
+			"conf.yml":                 tt.conf,
+			"group1/configs/tests.yml": tt.tests,
+		})
+		_, err := loader.Load("conf.yml")
+		if err == nil {
+			t.Fatalf("%d failed an unknown key should be reported", i)
+		}
+		if tt.expected != nil && !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+}
+
+func TestLoadExtraKeys(t *testing.T) {
+	loader := New()
+	loader.fileOpener = getTestFileOpener(map[string]string{
+		"conf.yml":                 "url: https://localhost:8000\nx-ci: { retries: 2 }\ngroups:\n  group1:\n    owner: team-a\n    tests:\n      - tests.yml",
+		"group1/configs/tests.yml": "x-generated-by: tool\nunit_tests:\n  GET:\n    - { url: \"/articles\", status: 200 }\n",
+	})
+	config, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if len(config.Groups["group1"].UnitTests) != 1 {
+		t.Fatalf("failed unit tests %#v", config.Groups["group1"].UnitTests)
+	}
+}
@@ -0,0 +1,83 @@
+package testerconfig
+
+import (
+	"fmt"
+	"mime"
+	"path"
+)
+
+var (
+	ErrFormWithIn             = fmt.Errorf("A test can't have both `form` and `in`")
+	ErrFormFile               = fmt.Errorf("Invalid form file, expected a name and a path")
+	ErrFormUrlencodedWithBody = fmt.Errorf("A test can't have `formUrlencoded` with `in` or `form`")
+)
+
+// Form is a multipart/form-data body.
+type Form struct {
+	Fields map[string][]string
+	Files  []FormFile
+}
+
+type FormFile struct {
+	Name        string
+	Filename    string
+	ContentType string
+	// Path of the file from the folder where Madelyne is run.
+	Path    string
+	Content []byte
+}
+
+type ymlForm struct {
+	Fields ymlValues     `yaml:"fields"`
+	Files  []ymlFormFile `yaml:"files"`
+}
+
+type ymlFormFile struct {
+	Name        string `yaml:"name"`
+	Path        string `yaml:"path"`
+	Filename    string `yaml:"filename"`
+	ContentType string `yaml:"contentType"`
+}
+
+// loadForm reads the files of the form from the payloads of the group. The
+// filename defaults to the one of the path and the content type is guessed
+// from its extension.
+func (cl ConfigLoader) loadForm(yf *ymlForm, group string) (*Form, error) {
+	if yf == nil {
+		return nil, nil
+	}
+	out := &Form{
+		Fields: map[string][]string(yf.Fields),
+		Files:  []FormFile{},
+	}
+	if out.Fields == nil {
+		out.Fields = map[string][]string{}
+	}
+	for _, f := range yf.Files {
+		if f.Name == "" || f.Path == "" {
+			return nil, fmt.Errorf("%w : %s", ErrFormFile, f.Path)
+		}
+		file := FormFile{
+			Name:        f.Name,
+			Filename:    f.Filename,
+			ContentType: f.ContentType,
+			Path:        group + "/payloads/" + f.Path,
+		}
+		if file.Filename == "" {
+			file.Filename = path.Base(f.Path)
+		}
+		if file.ContentType == "" {
+			file.ContentType = mime.TypeByExtension(path.Ext(f.Path))
+		}
+		if file.ContentType == "" {
+			file.ContentType = "application/octet-stream"
+		}
+		content, err := cl.loadFile(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		file.Content = content
+		out.Files = append(out.Files, file)
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package testerconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+var ErrInvalidGraphql = fmt.Errorf("Invalid graphql, a GRAPHQL test needs a `graphql` block with a query and can't have `in`")
+
+type ymlGraphql struct {
+	// name of a .graphql file in payloads
+	Query string `yaml:"query"`
+	// name of a json file in payloads or the variables themselves
+	Variables     interface{} `yaml:"variables"`
+	OperationName string      `yaml:"operationName"`
+}
+
+// loadGraphql builds the json body of a GRAPHQL test as its In, so its
+// variables are substituted like any payload, and loads the expected errors.
+func (cl ConfigLoader) loadGraphql(v ymlUnitTest, u *UnitTest, group string) error {
+	if u.Action != "GRAPHQL" {
+		if v.Graphql != nil || v.OutErrors != "" {
+			return fmt.Errorf("in %s : %w : `graphql` and `outErrors` need the GRAPHQL action", u.File, ErrInvalidGraphql)
+		}
+		return nil
+	}
+	if v.Graphql == nil || v.Graphql.Query == "" || len(v.In) > 0 {
+		return fmt.Errorf("in %s : %w", u.File, ErrInvalidGraphql)
+	}
+
+	query, err := cl.loadFile(group + "/payloads/" + v.Graphql.Query + ".graphql")
+	if err != nil {
+		return err
+	}
+	var variables []byte
+	switch vars := v.Graphql.Variables.(type) {
+	case nil:
+	case string:
+		// kept as text, so `"id": #id#` gives a number once substituted
+		variables, err = cl.loadFile(group + "/payloads/" + vars + ".json")
+		if err != nil {
+			return err
+		}
+		variables = bytes.TrimSpace(variables)
+	default:
+		variables, err = json.Marshal(vars)
+		if err != nil {
+			return fmt.Errorf("in %s : %w : %v", u.File, ErrInvalidGraphql, err)
+		}
+	}
+	u.In = graphqlBody(string(query), variables, v.Graphql.OperationName)
+	u.CtIn = "application/json"
+
+	if v.OutErrors != "" {
+		u.OutErrors, err = cl.loadFile(group + "/responses/" + v.OutErrors + ".json")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// graphqlBody writes the json body by hand, as the variables may only be valid
+// json once substituted.
+func graphqlBody(query string, variables []byte, operationName string) []byte {
+	q, _ := json.Marshal(query)
+	body := `{"query":` + string(q)
+	if len(variables) > 0 {
+		body += `,"variables":` + string(variables)
+	}
+	if operationName != "" {
+		o, _ := json.Marshal(operationName)
+		body += `,"operationName":` + string(o)
+	}
+	return []byte(body + "}")
+}
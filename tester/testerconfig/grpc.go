@@ -0,0 +1,101 @@
+package testerconfig
+
+import (
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"net/url"
+)
+
+var (
+	ErrInvalidGrpc       = fmt.Errorf("Invalid grpc configuration")
+	ErrInvalidGrpcStatus = fmt.Errorf("Invalid grpcStatus, expected a status name like OK or NOT_FOUND")
+)
+
+// grpcCodes are the names of the grpc status codes, as written in the
+// specification.
+var grpcCodes = map[string]uint32{
+	"OK":                  0,
+	"CANCELLED":           1,
+	"UNKNOWN":             2,
+	"INVALID_ARGUMENT":    3,
+	"DEADLINE_EXCEEDED":   4,
+	"NOT_FOUND":           5,
+	"ALREADY_EXISTS":      6,
+	"PERMISSION_DENIED":   7,
+	"RESOURCE_EXHAUSTED":  8,
+	"FAILED_PRECONDITION": 9,
+	"ABORTED":             10,
+	"OUT_OF_RANGE":        11,
+	"UNIMPLEMENTED":       12,
+	"INTERNAL":            13,
+	"UNAVAILABLE":         14,
+	"DATA_LOSS":           15,
+	"UNAUTHENTICATED":     16,
+}
+
+// GrpcCodeName gives the name used in the test files of a grpc status code.
+func GrpcCodeName(code uint32) string {
+	for name, c := range grpcCodes {
+		if c == code {
+			return name
+		}
+	}
+	return fmt.Sprintf("CODE_%d", code)
+}
+
+// GrpcConfig describes the grpc server called by the GRPC tests, its services
+// being read from a FileDescriptorSet instead of the reflection service.
+type GrpcConfig struct {
+	Address   string
+	Plaintext bool
+	// DescriptorsFile is the path of the FileDescriptorSet, e.g. generated by
+	// `protoc --include_imports --descriptor_set_out`.
+	DescriptorsFile string
+	Descriptors     *protoregistry.Files
+}
+
+type ymlGrpcConfig struct {
+	Address     string `yaml:"address"`
+	Descriptors string `yaml:"descriptors"`
+	Plaintext   bool   `yaml:"plaintext"`
+}
+
+// loadGrpc reads the descriptors, the address defaults to the host of the url
+// of the suite.
+func (cl ConfigLoader) loadGrpc(yg *ymlGrpcConfig, suiteUrl string) (*GrpcConfig, error) {
+	if yg == nil {
+		return nil, nil
+	}
+	out := &GrpcConfig{
+		Address:         yg.Address,
+		Plaintext:       yg.Plaintext,
+		DescriptorsFile: yg.Descriptors,
+	}
+	if out.Address == "" {
+		u, err := url.Parse(suiteUrl)
+		if err != nil {
+			return nil, fmt.Errorf("%w : %v", ErrInvalidGrpc, err)
+		}
+		out.Address = u.Host
+	}
+	if yg.Descriptors == "" {
+		return nil, fmt.Errorf("%w : `descriptors` is required", ErrInvalidGrpc)
+	}
+	data, err := cl.loadFile(yg.Descriptors)
+	if err != nil {
+		return nil, err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	err = proto.Unmarshal(data, set)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s : %v", ErrInvalidGrpc, yg.Descriptors, err)
+	}
+	out.Descriptors, err = protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s : %v", ErrInvalidGrpc, yg.Descriptors, err)
+	}
+	return out, nil
+}
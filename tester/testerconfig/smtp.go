@@ -0,0 +1,80 @@
+package testerconfig
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var (
+	ErrInvalidSmtp  = fmt.Errorf("Invalid smtp configuration")
+	ErrInvalidEmail = fmt.Errorf("Invalid email, only EXPECT_EMAIL steps have an `email` block")
+)
+
+// SmtpConfig describes the SMTP server started for a group, whose host and
+// port are given to the tests as `smtp_host` and `smtp_port`.
+type SmtpConfig struct {
+	// Port is 0 when any free port can be used.
+	Port int
+}
+
+// EmailAssertion describes the email waited for by an EXPECT_EMAIL step. The
+// fields are literals or matcher patterns, empty when not checked. The email
+// must be sent to one of the recipients matching To, with a subject matching
+// Subject. Capture gives the regexp applied on the body for each variable
+// captured in the environment.
+type EmailAssertion struct {
+	To      string
+	From    string
+	Subject string
+	Body    string
+	Capture map[string]string
+}
+
+type ymlSmtpConfig struct {
+	Port int `yaml:"port"`
+}
+
+type ymlEmailAssertion struct {
+	To      string            `yaml:"to"`
+	From    string            `yaml:"from"`
+	Subject string            `yaml:"subject"`
+	Body    string            `yaml:"body"`
+	Capture map[string]string `yaml:"capture"`
+}
+
+// loadSmtp gives the smtp configuration of the group, a default one when only
+// its tests expect emails.
+func loadSmtp(ys *ymlSmtpConfig, units []UnitTest, scenarios map[string][]UnitTest) (*SmtpConfig, error) {
+	if ys != nil {
+		if ys.Port < 0 || ys.Port > 65535 {
+			return nil, fmt.Errorf("%w : port %d", ErrInvalidSmtp, ys.Port)
+		}
+		return &SmtpConfig{Port: ys.Port}, nil
+	}
+	tests := append([]UnitTest{}, units...)
+	for _, steps := range scenarios {
+		tests = append(tests, steps...)
+	}
+	for _, ut := range tests {
+		if ut.Action == "EXPECT_EMAIL" {
+			return &SmtpConfig{}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (ye *ymlEmailAssertion) toEmailAssertion() (*EmailAssertion, error) {
+	for name, expression := range ye.Capture {
+		_, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("%w : capture of %s : %v", ErrInvalidEmail, name, err)
+		}
+	}
+	return &EmailAssertion{
+		To:      ye.To,
+		From:    ye.From,
+		Subject: ye.Subject,
+		Body:    ye.Body,
+		Capture: ye.Capture,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package testerconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidSse = fmt.Errorf("Invalid sse, expected a count of at least the number of events and a duration as timeout")
+	ErrSseWithOut = fmt.Errorf("A test can't have both `sse` and `out`")
+)
+
+// SseAssertion reads a text/event-stream response until Count events are
+// received or Timeout is reached, then compares them with Events in order.
+type SseAssertion struct {
+	Count   int
+	Timeout time.Duration
+	Events  []SseEvent
+}
+
+// SseEvent is an expected event. Event and Id are literals or matcher
+// patterns, empty when not checked. Data is compared as json by the
+// comparator, nil when not checked.
+type SseEvent struct {
+	Event string
+	Id    string
+	Data  interface{}
+}
+
+type ymlSse struct {
+	Count   int           `yaml:"count"`
+	Timeout string        `yaml:"timeout"`
+	Events  []ymlSseEvent `yaml:"events"`
+}
+
+type ymlSseEvent struct {
+	Event string      `yaml:"event"`
+	Id    string      `yaml:"id"`
+	Data  interface{} `yaml:"data"`
+}
+
+func (ys *ymlSse) toSseAssertion() (*SseAssertion, error) {
+	out := &SseAssertion{
+		Count:   ys.Count,
+		Timeout: DefaultTimeout,
+		Events:  []SseEvent{},
+	}
+	if out.Count == 0 {
+		out.Count = len(ys.Events)
+	}
+	if out.Count < len(ys.Events) {
+		return nil, fmt.Errorf("%w : count %d for %d events", ErrInvalidSse, out.Count, len(ys.Events))
+	}
+	if ys.Timeout != "" {
+		timeout, err := time.ParseDuration(ys.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("%w : timeout %s", ErrInvalidSse, ys.Timeout)
+		}
+		out.Timeout = timeout
+	}
+	for _, e := range ys.Events {
+		data, err := toJsonValue(e.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%w : %v", ErrInvalidSse, err)
+		}
+		out.Events = append(out.Events, SseEvent{Event: e.Event, Id: e.Id, Data: data})
+	}
+	return out, nil
+}
+
+// toJsonValue gives the value decoded from yaml with the types of a value
+// decoded from json, as the comparator expects them.
+func toJsonValue(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
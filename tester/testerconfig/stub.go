@@ -0,0 +1,191 @@
+package testerconfig
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidStub     = fmt.Errorf("Invalid stub")
+	ErrInvalidStubStep = fmt.Errorf("Invalid STUB step, expected a `stub` block with the name of a stub of the group")
+)
+
+// Stub is a local http server started for the group, whose url is given to
+// the tests as the `stub_<name>` variable.
+type Stub struct {
+	Name string
+	// Port is 0 when any free port can be used.
+	Port   int
+	Routes []StubRoute
+}
+
+// StubRoute is the response of a stub to the requests matching Method and
+// Url, Url being compared to the path or, when it has a query, to the whole
+// request uri.
+type StubRoute struct {
+	Method  string
+	Url     string
+	Status  int
+	CtOut   string
+	Headers map[string][]string
+	Out     []byte
+	// Latency is waited before answering.
+	Latency time.Duration
+	// Error is `close` when the connection is closed instead of answered.
+	Error string
+}
+
+// StubAssertion checks the calls received by a stub since the start of the
+// unit test or scenario. Method and Url filter the calls when not empty, Calls
+// is the expected count, -1 when not checked. Bodies are compared as json by
+// the comparator with the bodies of the first calls, in order.
+type StubAssertion struct {
+	Name   string
+	Method string
+	Url    string
+	Calls  int
+	Bodies []interface{}
+}
+
+type ymlStub struct {
+	Port   int            `yaml:"port"`
+	Routes []ymlStubRoute `yaml:"routes"`
+}
+
+type ymlStubRoute struct {
+	Method  string     `yaml:"method"`
+	Url     string     `yaml:"url"`
+	Status  int        `yaml:"status"`
+	CtOut   string     `yaml:"ct_out"`
+	Headers ymlHeaders `yaml:"headers"`
+	Out     string     `yaml:"out"`
+	Latency string     `yaml:"latency"`
+	Error   string     `yaml:"error"`
+}
+
+type ymlStubAssertion struct {
+	Name   string        `yaml:"name"`
+	Method string        `yaml:"method"`
+	Url    string        `yaml:"url"`
+	Calls  *int          `yaml:"calls"`
+	Bodies []interface{} `yaml:"bodies"`
+}
+
+// loadStubs gives the stubs of the group sorted by name, their responses being
+// read in the responses folder like the ones of the tests.
+func (cl ConfigLoader) loadStubs(group string, ys map[string]ymlStub) ([]Stub, error) {
+	if len(ys) == 0 {
+		return nil, nil
+	}
+	names := make([]string, 0, len(ys))
+	for name := range ys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := []Stub{}
+	for _, name := range names {
+		s := Stub{Name: name, Port: ys[name].Port, Routes: []StubRoute{}}
+		if s.Port < 0 || s.Port > 65535 {
+			return nil, fmt.Errorf("%w %s : port %d", ErrInvalidStub, name, s.Port)
+		}
+		for i, yr := range ys[name].Routes {
+			r, err := cl.loadStubRoute(group, yr)
+			if err != nil {
+				return nil, fmt.Errorf("%w %s, route %d : %v", ErrInvalidStub, name, i, err)
+			}
+			s.Routes = append(s.Routes, r)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (cl ConfigLoader) loadStubRoute(group string, yr ymlStubRoute) (StubRoute, error) {
+	r := StubRoute{
+		Method:  strings.ToUpper(yr.Method),
+		Url:     yr.Url,
+		Status:  yr.Status,
+		CtOut:   yr.CtOut,
+		Headers: map[string][]string(yr.Headers),
+		Error:   yr.Error,
+	}
+	if r.Method == "" || r.Url == "" {
+		return StubRoute{}, fmt.Errorf("method and url are required")
+	}
+	if r.Status == 0 {
+		r.Status = http.StatusOK
+	}
+	if r.Headers == nil {
+		r.Headers = map[string][]string{}
+	}
+	if r.Error != "" && r.Error != "close" {
+		return StubRoute{}, fmt.Errorf("error must be close, got %s", r.Error)
+	}
+	if yr.Latency != "" {
+		latency, err := time.ParseDuration(yr.Latency)
+		if err != nil || latency < 0 {
+			return StubRoute{}, fmt.Errorf("latency must be a duration like 200ms, got %s", yr.Latency)
+		}
+		r.Latency = latency
+	}
+	if yr.Out != "" {
+		out, err := cl.loadFile(group + "/responses/" + yr.Out + getExtension(r.CtOut))
+		if err != nil {
+			return StubRoute{}, err
+		}
+		r.Out = out
+		if r.CtOut == "" {
+			r.CtOut = "application/json"
+		}
+	}
+	return r, nil
+}
+
+func (ys *ymlStubAssertion) toStubAssertion() (*StubAssertion, error) {
+	if ys.Name == "" {
+		return nil, ErrInvalidStubStep
+	}
+	out := &StubAssertion{
+		Name:   ys.Name,
+		Method: strings.ToUpper(ys.Method),
+		Url:    ys.Url,
+		Calls:  -1,
+		Bodies: []interface{}{},
+	}
+	if ys.Calls != nil {
+		if *ys.Calls < 0 {
+			return nil, fmt.Errorf("%w : calls %d", ErrInvalidStubStep, *ys.Calls)
+		}
+		out.Calls = *ys.Calls
+	}
+	for _, b := range ys.Bodies {
+		body, err := toJsonValue(b)
+		if err != nil {
+			return nil, fmt.Errorf("%w : %v", ErrInvalidStubStep, err)
+		}
+		out.Bodies = append(out.Bodies, body)
+	}
+	return out, nil
+}
+
+// checkStubSteps verifies that the STUB steps check stubs of the group.
+func checkStubSteps(stubs []Stub, units []UnitTest, scenarios map[string][]UnitTest) error {
+	names := map[string]bool{}
+	for _, s := range stubs {
+		names[s.Name] = true
+	}
+	tests := append([]UnitTest{}, units...)
+	for _, steps := range scenarios {
+		tests = append(tests, steps...)
+	}
+	for _, ut := range tests {
+		if ut.Stub != nil && !names[ut.Stub.Name] {
+			return fmt.Errorf("in %s : %w : unknown stub %s", ut.File, ErrInvalidStubStep, ut.Stub.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package testerconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+)
+
+var (
+	ErrInvalidTls        = fmt.Errorf("Invalid tls configuration")
+	ErrInvalidTlsVersion = fmt.Errorf("Invalid tls version, expected 1.0, 1.1, 1.2 or 1.3")
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TlsVersionName gives the name used in the config files of a tls version.
+func TlsVersionName(version uint16) string {
+	for name, v := range tlsVersions {
+		if v == version {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+type ymlTlsConfig struct {
+	Ca                 string `yaml:"ca"`
+	Cert               string `yaml:"cert"`
+	Key                string `yaml:"key"`
+	ServerName         string `yaml:"serverName"`
+	MinVersion         string `yaml:"minVersion"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+}
+
+func (cl ConfigLoader) loadTls(yt *ymlTlsConfig) (*tls.Config, error) {
+	if yt == nil {
+		return nil, nil
+	}
+	out := &tls.Config{
+		ServerName:         yt.ServerName,
+		InsecureSkipVerify: yt.InsecureSkipVerify,
+	}
+
+	if yt.MinVersion != "" {
+		v, ok := tlsVersions[yt.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("%w : minVersion %s", ErrInvalidTlsVersion, yt.MinVersion)
+		}
+		out.MinVersion = v
+	}
+
+	if yt.Ca != "" {
+		data, err := cl.loadFile(yt.Ca)
+		if err != nil {
+			return nil, fmt.Errorf("while loading ca %s : %w", yt.Ca, err)
+		}
+		out.RootCAs = x509.NewCertPool()
+		if !out.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%w : no certificate found in ca %s", ErrInvalidTls, yt.Ca)
+		}
+	}
+
+	if (yt.Cert == "") != (yt.Key == "") {
+		return nil, fmt.Errorf("%w : cert and key must be given together", ErrInvalidTls)
+	}
+	if yt.Cert != "" {
+		cert, err := cl.loadFile(yt.Cert)
+		if err != nil {
+			return nil, fmt.Errorf("while loading cert %s : %w", yt.Cert, err)
+		}
+		key, err := cl.loadFile(yt.Key)
+		if err != nil {
+			return nil, fmt.Errorf("while loading key %s : %w", yt.Key, err)
+		}
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("%w : %v", ErrInvalidTls, err)
+		}
+		out.Certificates = []tls.Certificate{pair}
+	}
+	return out, nil
+}
@@ -0,0 +1,105 @@
+package testerconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func generateCert(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "madelyne"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return string(cert), string(keyPem)
+}
+
+func TestLoadTls(t *testing.T) {
+	cert, key := generateCert(t)
+	tests := []struct {
+		tls      string
+		expected error
+	}{
+		{tls: "{ ca: certs/ca.pem, cert: certs/client.pem, key: certs/client-key.pem, serverName: api.internal, minVersion: \"1.2\" }"},
+		{tls: "{ insecureSkipVerify: true }"},
+		{tls: "{ minVersion: \"1.4\" }", expected: ErrInvalidTlsVersion},
+		{tls: "{ cert: certs/client.pem }", expected: ErrInvalidTls},
+		{tls: "{ ca: certs/client-key.pem }", expected: ErrInvalidTls},
+		{tls: "{ cert: certs/client.pem, key: certs/ca.pem }", expected: ErrInvalidTls},
+	}
+
+	for i, tt := range tests {
+		loader := New()
+		loader.fileOpener = getTestFileOpener(map[string]string{
+			"conf.yml":             "url: https://localhost:8000\nhttp:\n  tls: " + tt.tls + "\ngroups: {}",
+			"certs/ca.pem":         cert,
+			"certs/client.pem":     cert,
+			"certs/client-key.pem": key,
+		})
+		result, err := loader.Load("conf.yml")
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+		if err != nil {
+			continue
+		}
+		if result.Http.Tls == nil {
+			t.Fatalf("%d failed tls config is missing", i)
+		}
+		if i == 0 {
+			c := result.Http.Tls
+			if c.ServerName != "api.internal" || c.MinVersion != tls.VersionTLS12 || c.RootCAs == nil || len(c.Certificates) != 1 {
+				t.Fatalf("%d failed got %#v", i, c)
+			}
+		}
+	}
+}
+
+func TestLoadOutTls(t *testing.T) {
+	filesystem := map[string]string{
+		"conf.yml":                 "url: https://localhost:8000\ngroups:\n  group1:\n    tests:\n      - tests.yml",
+		"group1/configs/tests.yml": "unit_tests:\n  GET:\n    - { url: \"/\", outTls: { version: \"1.3\", subject: \"CN=@string@\" } }\n",
+	}
+	loader := New()
+	loader.fileOpener = getTestFileOpener(filesystem)
+	result, err := loader.Load("conf.yml")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	expected := &TlsAssertion{Version: "1.3", Subject: "CN=@string@"}
+	if !reflect.DeepEqual(result.Groups["group1"].UnitTests[0].OutTls, expected) {
+		t.Fatalf("failed got %#v", result.Groups["group1"].UnitTests[0].OutTls)
+	}
+
+	filesystem["group1/configs/tests.yml"] = "unit_tests:\n  GET:\n    - { url: \"/\", outTls: { version: \"TLSv1.3\" } }\n"
+	_, err = loader.Load("conf.yml")
+	if !errors.Is(err, ErrInvalidTlsVersion) {
+		t.Fatalf("failed got %v, exp %v", err, ErrInvalidTlsVersion)
+	}
+}
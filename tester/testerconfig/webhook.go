@@ -0,0 +1,76 @@
+package testerconfig
+
+import (
+	"fmt"
+)
+
+var (
+	ErrInvalidWebhook   = fmt.Errorf("Invalid webhooks configuration")
+	ErrInvalidSignature = fmt.Errorf("Invalid signature, expected a header, a secret, an algorithm among sha1, sha256 and sha512 and an encoding among hex and base64")
+)
+
+// WebhookConfig describes the receiver of the callbacks checked by the
+// EXPECT_WEBHOOK steps, whose url is given to the tests as `webhook_url`.
+type WebhookConfig struct {
+	// Port is 0 when any free port can be used.
+	Port int
+}
+
+// SignatureAssertion checks the HMAC of the body of a callback, sent in Header
+// as Prefix followed by the encoded HMAC. Secret may contain `#var#`.
+type SignatureAssertion struct {
+	Header    string `yaml:"header"`
+	Secret    string `yaml:"secret"`
+	Algorithm string `yaml:"algorithm"`
+	Encoding  string `yaml:"encoding"`
+	Prefix    string `yaml:"prefix"`
+}
+
+type ymlWebhookConfig struct {
+	Port int `yaml:"port"`
+}
+
+// loadWebhooks gives the receiver configuration, a default one when only the
+// tests expect webhooks.
+func loadWebhooks(yw *ymlWebhookConfig, groups map[string]TestGroup) (*WebhookConfig, error) {
+	if yw != nil {
+		if yw.Port < 0 || yw.Port > 65535 {
+			return nil, fmt.Errorf("%w : port %d", ErrInvalidWebhook, yw.Port)
+		}
+		return &WebhookConfig{Port: yw.Port}, nil
+	}
+	for _, g := range groups {
+		tests := append([]UnitTest{}, g.UnitTests...)
+		for _, steps := range g.Scenarios {
+			tests = append(tests, steps...)
+		}
+		for _, ut := range tests {
+			if ut.Action == "EXPECT_WEBHOOK" {
+				return &WebhookConfig{}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func toSignatureAssertion(s *SignatureAssertion) (*SignatureAssertion, error) {
+	out := *s
+	if out.Algorithm == "" {
+		out.Algorithm = "sha256"
+	}
+	if out.Encoding == "" {
+		out.Encoding = "hex"
+	}
+	if out.Header == "" || out.Secret == "" {
+		return nil, ErrInvalidSignature
+	}
+	switch out.Algorithm {
+	case "sha1", "sha256", "sha512":
+	default:
+		return nil, fmt.Errorf("%w : algorithm %s", ErrInvalidSignature, out.Algorithm)
+	}
+	if out.Encoding != "hex" && out.Encoding != "base64" {
+		return nil, fmt.Errorf("%w : encoding %s", ErrInvalidSignature, out.Encoding)
+	}
+	return &out, nil
+}
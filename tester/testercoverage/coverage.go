@@ -0,0 +1,267 @@
+package testercoverage
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerclient"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidSpec = fmt.Errorf("Invalid OpenAPI file")
+)
+
+var specMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+type Operation struct {
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Statuses []int  `json:"statuses"`
+}
+
+type Report struct {
+	Covered   []Operation `json:"covered"`
+	Uncovered []Operation `json:"uncovered"`
+	Unmatched []string    `json:"unmatched"`
+}
+
+type operation struct {
+	method   string
+	path     string
+	segments []string
+	statuses map[int]bool
+	covered  bool
+}
+
+type Coverage struct {
+	basePaths  []string
+	operations []*operation
+	unmatched  map[string]bool
+}
+
+type ymlSpec struct {
+	BasePath string `yaml:"basePath"`
+	Servers  []struct {
+		Url string `yaml:"url"`
+	} `yaml:"servers"`
+	Paths map[string]map[string]interface{} `yaml:"paths"`
+}
+
+func Load(filename string) (*Coverage, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	return New(data)
+}
+
+// New builds a coverage from an OpenAPI 3 or Swagger 2 document, in yaml or json.
+func New(spec []byte) (*Coverage, error) {
+	ys := ymlSpec{}
+	err := yaml.Unmarshal(spec, &ys)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %v", ErrInvalidSpec, err)
+	}
+	if len(ys.Paths) == 0 {
+		return nil, fmt.Errorf("%w : no paths found", ErrInvalidSpec)
+	}
+
+	c := &Coverage{
+		basePaths:  []string{},
+		operations: []*operation{},
+		unmatched:  map[string]bool{},
+	}
+	if ys.BasePath != "" && ys.BasePath != "/" {
+		c.basePaths = append(c.basePaths, strings.TrimSuffix(ys.BasePath, "/"))
+	}
+	for _, s := range ys.Servers {
+		u, err := url.Parse(s.Url)
+		if err != nil || u.Path == "" || u.Path == "/" {
+			continue
+		}
+		c.basePaths = append(c.basePaths, strings.TrimSuffix(u.Path, "/"))
+	}
+
+	paths := make([]string, 0, len(ys.Paths))
+	for p := range ys.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		for _, m := range specMethods {
+			if _, ok := ys.Paths[p][m]; !ok {
+				continue
+			}
+			c.operations = append(c.operations, &operation{
+				method:   strings.ToUpper(m),
+				path:     p,
+				segments: splitPath(p),
+				statuses: map[int]bool{},
+			})
+		}
+	}
+	return c, nil
+}
+
+// Record marks the operation matching the method and url as covered by the status.
+func (c *Coverage) Record(method string, rawUrl string, status int) {
+	method = strings.ToUpper(method)
+	path := rawUrl
+	u, err := url.Parse(rawUrl)
+	if err == nil {
+		path = u.Path
+	}
+
+	op := c.match(method, path)
+	if op == nil {
+		c.unmatched[method+" "+path] = true
+		return
+	}
+	op.covered = true
+	if status > 0 {
+		op.statuses[status] = true
+	}
+}
+
+func (c *Coverage) match(method string, path string) *operation {
+	candidates := []string{path}
+	for _, base := range c.basePaths {
+		if strings.HasPrefix(path, base) {
+			candidates = append(candidates, strings.TrimPrefix(path, base))
+		}
+	}
+
+	var best *operation
+	bestScore := -1
+	for _, candidate := range candidates {
+		segments := splitPath(candidate)
+		for _, op := range c.operations {
+			if op.method != method {
+				continue
+			}
+			score, ok := matchSegments(op.segments, segments)
+			if ok && score > bestScore {
+				best = op
+				bestScore = score
+			}
+		}
+	}
+	return best
+}
+
+func matchSegments(template []string, segments []string) (int, bool) {
+	if len(template) != len(segments) {
+		return 0, false
+	}
+	score := 0
+	for i, t := range template {
+		if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}") {
+			if segments[i] == "" {
+				return 0, false
+			}
+			continue
+		}
+		if t != segments[i] {
+			return 0, false
+		}
+		score++
+	}
+	return score, true
+}
+
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return []string{}
+	}
+	return strings.Split(p, "/")
+}
+
+func (c *Coverage) Report() Report {
+	r := Report{
+		Covered:   []Operation{},
+		Uncovered: []Operation{},
+		Unmatched: []string{},
+	}
+	for _, op := range c.operations {
+		o := Operation{
+			Method:   op.method,
+			Path:     op.path,
+			Statuses: []int{},
+		}
+		for s := range op.statuses {
+			o.Statuses = append(o.Statuses, s)
+		}
+		sort.Ints(o.Statuses)
+		if op.covered {
+			r.Covered = append(r.Covered, o)
+		} else {
+			r.Uncovered = append(r.Uncovered, o)
+		}
+	}
+	for u := range c.unmatched {
+		r.Unmatched = append(r.Unmatched, u)
+	}
+	sort.Strings(r.Unmatched)
+	return r
+}
+
+// Wrap records every response going through the requester.
+func (c *Coverage) Wrap(r testerclient.Requester) testerclient.Requester {
+	return &requester{
+		next:     r,
+		coverage: c,
+	}
+}
+
+type requester struct {
+	next     testerclient.Requester
+	coverage *Coverage
+}
+
+func (r *requester) Make(request testerclient.Request) (testerclient.Response, error) {
+	response, err := r.next.Make(request)
+	if err == nil {
+		r.coverage.Record(request.Method, request.Url, response.StatusCode)
+	}
+	return response, err
+}
+
+func (r Report) Print(w io.Writer) {
+	total := len(r.Covered) + len(r.Uncovered)
+	percent := 0
+	if total > 0 {
+		percent = len(r.Covered) * 100 / total
+	}
+	fmt.Fprintf(w, "Endpoint coverage: %d/%d operations (%d%%)\n", len(r.Covered), total, percent)
+	for _, o := range r.Covered {
+		statuses := make([]string, 0, len(o.Statuses))
+		for _, s := range o.Statuses {
+			statuses = append(statuses, fmt.Sprintf("%d", s))
+		}
+		fmt.Fprintf(w, "  [x] %s %s %s\n", o.Method, o.Path, strings.Join(statuses, ", "))
+	}
+	for _, o := range r.Uncovered {
+		fmt.Fprintf(w, "  [ ] %s %s\n", o.Method, o.Path)
+	}
+	if len(r.Unmatched) > 0 {
+		fmt.Fprintln(w, "Requests matching no operation:")
+		for _, u := range r.Unmatched {
+			fmt.Fprintf(w, "  %s\n", u)
+		}
+	}
+}
+
+func (r Report) WriteJSON(filename string) error {
+	data, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Clean(filename), data, 0644)
+}
@@ -0,0 +1,137 @@
+package testercoverage
+
+import (
+	"errors"
+	"github.com/madelyne-io/madelyne/tester/testerclient"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSpec = `openapi: 3.0.0
+servers:
+  - url: http://localhost:3000/api
+paths:
+  /articles:
+    get: {}
+    post: {}
+  /articles/{id}:
+    get: {}
+    delete: {}
+  /articles/all:
+    get: {}
+`
+
+type fakeClient struct {
+	nextStatus int
+	nextError  error
+}
+
+func (fc *fakeClient) Make(r testerclient.Request) (testerclient.Response, error) {
+	return testerclient.Response{StatusCode: fc.nextStatus}, fc.nextError
+}
+
+func TestReport(t *testing.T) {
+	tests := []struct {
+		requests []testerclient.Request
+		statuses []int
+		expected Report
+	}{
+		{
+			requests: []testerclient.Request{},
+			statuses: []int{},
+			expected: Report{
+				Covered: []Operation{},
+				Uncovered: []Operation{
+					{Method: "GET", Path: "/articles", Statuses: []int{}},
+					{Method: "POST", Path: "/articles", Statuses: []int{}},
+					{Method: "GET", Path: "/articles/all", Statuses: []int{}},
+					{Method: "GET", Path: "/articles/{id}", Statuses: []int{}},
+					{Method: "DELETE", Path: "/articles/{id}", Statuses: []int{}},
+				},
+				Unmatched: []string{},
+			},
+		},
+		{
+			requests: []testerclient.Request{
+				{Method: "GET", Url: "/articles/all"},
+				{Method: "GET", Url: "/articles/12?full=1"},
+				{Method: "GET", Url: "/api/articles/13"},
+				{Method: "DELETE", Url: "/articles/12"},
+				{Method: "POST", Url: "/articles/12"},
+				{Method: "get", Url: "/articles"},
+			},
+			statuses: []int{200, 404, 200, 204, 405, 200},
+			expected: Report{
+				Covered: []Operation{
+					{Method: "GET", Path: "/articles", Statuses: []int{200}},
+					{Method: "GET", Path: "/articles/all", Statuses: []int{200}},
+					{Method: "GET", Path: "/articles/{id}", Statuses: []int{200, 404}},
+					{Method: "DELETE", Path: "/articles/{id}", Statuses: []int{204}},
+				},
+				Uncovered: []Operation{
+					{Method: "POST", Path: "/articles", Statuses: []int{}},
+				},
+				Unmatched: []string{"POST /articles/12"},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		c, err := New([]byte(testSpec))
+		if err != nil {
+			t.Fatalf("%d failed %v", i, err)
+		}
+		client := &fakeClient{}
+		r := c.Wrap(client)
+		for j, request := range tt.requests {
+			client.nextStatus = tt.statuses[j]
+			_, err := r.Make(request)
+			if err != nil {
+				t.Fatalf("%d:%d failed %v", i, j, err)
+			}
+		}
+		result := c.Report()
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Fatalf("%d failed \n exp %#v \n got %#v", i, tt.expected, result)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected error
+	}{
+		{testSpec, nil},
+		{`{"swagger":"2.0","basePath":"/v1","paths":{"/a":{"get":{}}}}`, nil},
+		{`openapi: 3.0.0`, ErrInvalidSpec},
+		{`paths: [`, ErrInvalidSpec},
+	}
+
+	for i, tt := range tests {
+		_, err := New([]byte(tt.spec))
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+}
+
+func TestPrint(t *testing.T) {
+	r := Report{
+		Covered:   []Operation{{Method: "GET", Path: "/a", Statuses: []int{200, 404}}},
+		Uncovered: []Operation{{Method: "POST", Path: "/a", Statuses: []int{}}},
+		Unmatched: []string{"GET /b"},
+	}
+	expected := `Endpoint coverage: 1/2 operations (50%)
+  [x] GET /a 200, 404
+  [ ] POST /a
+Requests matching no operation:
+  GET /b
+`
+	w := &strings.Builder{}
+	r.Print(w)
+	if w.String() != expected {
+		t.Fatalf("failed exp \n%s\n, got \n%s\n", expected, w.String())
+	}
+}
@@ -0,0 +1,121 @@
+package testercurl
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Command builds a curl invocation equivalent to the request. Text bodies are
+// inlined, other ones are read from bodyFile when it is provided. A body sent
+// with a gzip Content-Encoding is compressed by piping it through gzip.
+func Command(method string, url string, headers map[string][]string, body []byte, bodyFile string) string {
+	parts := []string{"curl", "-i"}
+	if _, ok := header(headers, "Accept-Encoding"); ok {
+		parts = append(parts, "--compressed")
+	}
+	switch {
+	case method == "HEAD":
+		parts = append(parts, "--head")
+	case method != "GET" || body != nil:
+		parts = append(parts, "-X", method)
+	}
+	parts = append(parts, quote(url))
+
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range headers[k] {
+			parts = append(parts, "-H", quote(k+": "+v))
+		}
+	}
+
+	if body == nil {
+		return strings.Join(parts, " ")
+	}
+	source := ""
+	if bodyFile != "" && !isText(body) {
+		source = "@" + bodyFile
+	}
+	if encoding, _ := header(headers, "Content-Encoding"); strings.EqualFold(encoding, "gzip") {
+		parts = append(parts, "--data-binary", "@-")
+		if source != "" {
+			return "gzip -c " + quote(bodyFile) + " | " + strings.Join(parts, " ")
+		}
+		return "printf '%s' " + quote(string(body)) + " | gzip -c | " + strings.Join(parts, " ")
+	}
+	if source == "" {
+		source = string(body)
+	}
+	parts = append(parts, "--data-binary", quote(source))
+	return strings.Join(parts, " ")
+}
+
+// header gives the first value of a header whose name may not be canonical.
+func header(headers map[string][]string, name string) (string, bool) {
+	for k, values := range headers {
+		if strings.EqualFold(k, name) && len(values) > 0 {
+			return values[0], true
+		}
+	}
+	return "", false
+}
+
+type FormFile struct {
+	Name        string
+	Path        string
+	Filename    string
+	ContentType string
+}
+
+// FormCommand builds a curl invocation sending a multipart/form-data body, curl
+// setting the Content-Type with its own boundary.
+func FormCommand(method string, url string, headers map[string][]string, fields map[string][]string, files []FormFile) string {
+	parts := []string{"curl", "-i"}
+	if method != "POST" {
+		parts = append(parts, "-X", method)
+	}
+	parts = append(parts, quote(url))
+
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		if !strings.EqualFold(k, "Content-Type") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range headers[k] {
+			parts = append(parts, "-H", quote(k+": "+v))
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range fields[name] {
+			// --form-string doesn't read files for values starting with @ or <
+			parts = append(parts, "--form-string", quote(name+"="+v))
+		}
+	}
+	for _, f := range files {
+		parts = append(parts, "-F", quote(fmt.Sprintf("%s=@%s;filename=%s;type=%s", f.Name, f.Path, f.Filename, f.ContentType)))
+	}
+	return strings.Join(parts, " ")
+}
+
+func isText(data []byte) bool {
+	return utf8.Valid(data) && bytes.IndexByte(data, 0) < 0
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
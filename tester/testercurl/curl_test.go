@@ -0,0 +1,93 @@
+package testercurl
+
+import (
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		method   string
+		url      string
+		headers  map[string][]string
+		body     []byte
+		bodyFile string
+		expected string
+	}{
+		{
+			method:   "GET",
+			url:      "http://localhost:3000/articles?limit=10",
+			headers:  map[string][]string{},
+			expected: `curl -i 'http://localhost:3000/articles?limit=10'`,
+		},
+		{
+			method:   "DELETE",
+			url:      "http://localhost:3000/articles/1",
+			headers:  map[string][]string{"Content-Type": {"application/json"}, "Authorization": {"Bearer abc"}},
+			expected: `curl -i -X DELETE 'http://localhost:3000/articles/1' -H 'Authorization: Bearer abc' -H 'Content-Type: application/json'`,
+		},
+		{
+			method:   "POST",
+			url:      "http://localhost:3000/articles",
+			headers:  map[string][]string{"Content-Type": {"application/json"}},
+			body:     []byte(`{"title":"it's"}`),
+			bodyFile: "main/payloads/article.json",
+			expected: `curl -i -X POST 'http://localhost:3000/articles' -H 'Content-Type: application/json' --data-binary '{"title":"it'\''s"}'`,
+		},
+		{
+			method:   "PUT",
+			url:      "http://localhost:3000/file",
+			headers:  map[string][]string{"Content-Type": {"application/pdf"}},
+			body:     []byte{0x25, 0x00, 0xff},
+			bodyFile: "main/payloads/file.pdf",
+			expected: `curl -i -X PUT 'http://localhost:3000/file' -H 'Content-Type: application/pdf' --data-binary '@main/payloads/file.pdf'`,
+		},
+		{
+			method:   "HEAD",
+			url:      "http://localhost:3000/",
+			headers:  map[string][]string{},
+			expected: `curl -i --head 'http://localhost:3000/'`,
+		},
+		{
+			method:   "GET",
+			url:      "http://localhost:3000/articles",
+			headers:  map[string][]string{"Accept-Encoding": {"br"}},
+			expected: `curl -i --compressed 'http://localhost:3000/articles' -H 'Accept-Encoding: br'`,
+		},
+		{
+			method:   "POST",
+			url:      "http://localhost:3000/articles",
+			headers:  map[string][]string{"Content-Encoding": {"gzip"}},
+			body:     []byte(`{"title":"a"}`),
+			expected: `printf '%s' '{"title":"a"}' | gzip -c | curl -i -X POST 'http://localhost:3000/articles' -H 'Content-Encoding: gzip' --data-binary @-`,
+		},
+		{
+			method:   "PUT",
+			url:      "http://localhost:3000/file",
+			headers:  map[string][]string{"content-encoding": {"gzip"}},
+			body:     []byte{0x25, 0x00, 0xff},
+			bodyFile: "main/payloads/file.pdf",
+			expected: `gzip -c 'main/payloads/file.pdf' | curl -i -X PUT 'http://localhost:3000/file' -H 'content-encoding: gzip' --data-binary @-`,
+		},
+	}
+
+	for i, tt := range tests {
+		result := Command(tt.method, tt.url, tt.headers, tt.body, tt.bodyFile)
+		if result != tt.expected {
+			t.Fatalf("%d : failed got \n%s\n exp \n%s", i, result, tt.expected)
+		}
+	}
+}
+
+func TestFormCommand(t *testing.T) {
+	result := FormCommand(
+		"PUT",
+		"http://localhost:3000/upload",
+		map[string][]string{"Content-Type": {"multipart/form-data; boundary=abc"}, "Authorization": {"Bearer abc"}},
+		map[string][]string{"title": {"it's"}, "path": {"@/etc/passwd"}},
+		[]FormFile{{Name: "doc", Path: "main/payloads/file.pdf", Filename: "file.pdf", ContentType: "application/pdf"}},
+	)
+	expected := `curl -i -X PUT 'http://localhost:3000/upload' -H 'Authorization: Bearer abc' --form-string 'path=@/etc/passwd' --form-string 'title=it'\''s' -F 'doc=@main/payloads/file.pdf;filename=file.pdf;type=application/pdf'`
+	if result != expected {
+		t.Fatalf("failed got \n%s\n exp \n%s", result, expected)
+	}
+}
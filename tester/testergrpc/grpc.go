@@ -0,0 +1,140 @@
+package testergrpc
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownMethod  = fmt.Errorf("Unknown grpc method, expected `package.Service/Method`")
+	ErrInvalidPayload = fmt.Errorf("Invalid grpc payload")
+)
+
+type Invoker interface {
+	Invoke(method string, headers map[string][]string, payload []byte, timeout time.Duration) (Response, error)
+}
+
+type Options struct {
+	Address     string
+	Plaintext   bool
+	Tls         *tls.Config
+	Descriptors *protoregistry.Files
+}
+
+// Response of an unary call, Body being the json of the message when Code is
+// OK.
+type Response struct {
+	Code    uint32
+	Message string
+	Body    []byte
+	Headers map[string][]string
+}
+
+// Client calls the unary methods described by the descriptors, messages being
+// converted from and to json.
+type Client struct {
+	options Options
+	once    sync.Once
+	conn    *grpc.ClientConn
+	err     error
+}
+
+// New gives a client connecting on its first call.
+func New(o Options) *Client {
+	return &Client{options: o}
+}
+
+func (c *Client) connect() (*grpc.ClientConn, error) {
+	c.once.Do(func() {
+		creds := insecure.NewCredentials()
+		if !c.options.Plaintext {
+			tlsConfig := &tls.Config{}
+			if c.options.Tls != nil {
+				tlsConfig = c.options.Tls.Clone()
+			}
+			creds = credentials.NewTLS(tlsConfig)
+		}
+		c.conn, c.err = grpc.Dial(c.options.Address, grpc.WithTransportCredentials(creds))
+	})
+	return c.conn, c.err
+}
+
+// Invoke calls method, written `package.Service/Method`, with the json
+// payload. An error is only returned when the call can't be made, a failed
+// call gives its status in the response.
+func (c *Client) Invoke(method string, headers map[string][]string, payload []byte, timeout time.Duration) (Response, error) {
+	md, err := c.findMethod(method)
+	if err != nil {
+		return Response{}, err
+	}
+	in := dynamicpb.NewMessage(md.Input())
+	if len(payload) > 0 {
+		err = protojson.Unmarshal(payload, in)
+		if err != nil {
+			return Response{}, fmt.Errorf("%w : %v", ErrInvalidPayload, err)
+		}
+	}
+	conn, err := c.connect()
+	if err != nil {
+		return Response{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	outgoing := metadata.MD{}
+	for k, values := range headers {
+		outgoing.Append(k, values...)
+	}
+	ctx = metadata.NewOutgoingContext(ctx, outgoing)
+
+	out := dynamicpb.NewMessage(md.Output())
+	header := metadata.MD{}
+	err = conn.Invoke(ctx, "/"+string(md.Parent().FullName())+"/"+string(md.Name()), in, out, grpc.Header(&header))
+	st := status.Convert(err)
+	r := Response{
+		Code:    uint32(st.Code()),
+		Message: st.Message(),
+		Headers: header,
+	}
+	if err == nil {
+		r.Body, err = protojson.Marshal(out)
+		if err != nil {
+			return Response{}, err
+		}
+	}
+	return r, nil
+}
+
+func (c *Client) findMethod(method string) (protoreflect.MethodDescriptor, error) {
+	name := strings.Replace(strings.TrimPrefix(method, "/"), "/", ".", 1)
+	d, err := c.options.Descriptors.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s", ErrUnknownMethod, method)
+	}
+	md, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%w : %s", ErrUnknownMethod, method)
+	}
+	return md, nil
+}
+
+// Close closes the connection when it was opened.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
@@ -0,0 +1,151 @@
+package testergrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testDescriptors(t *testing.T) *protoregistry.Files {
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     kind.Enum(),
+		}
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("articles.proto"),
+		Package: proto.String("articles.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("GetRequest"), Field: []*descriptorpb.FieldDescriptorProto{field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING)}},
+			{Name: proto.String("Article"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("title", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("views", 3, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Articles"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Get"),
+				InputType:  proto.String(".articles.v1.GetRequest"),
+				OutputType: proto.String(".articles.v1.Article"),
+			}},
+		}},
+	}
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	return files
+}
+
+// startServer serves articles.v1.Articles/Get, answering the article 12 to
+// a request with the `authorization` metadata.
+func startServer(t *testing.T, files *protoregistry.Files) string {
+	method, _ := files.FindDescriptorByName("articles.v1.Articles.Get")
+	md := method.(protoreflect.MethodDescriptor)
+	handler := func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := dynamicpb.NewMessage(md.Input())
+		err := dec(in)
+		if err != nil {
+			return nil, err
+		}
+		incoming, _ := metadata.FromIncomingContext(ctx)
+		if len(incoming.Get("authorization")) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "missing token")
+		}
+		id := in.Get(md.Input().Fields().ByName("id")).String()
+		if id != "12" {
+			return nil, status.Errorf(codes.NotFound, "article %s not found", id)
+		}
+		out := dynamicpb.NewMessage(md.Output())
+		out.Set(md.Output().Fields().ByName("id"), protoreflect.ValueOfString(id))
+		out.Set(md.Output().Fields().ByName("title"), protoreflect.ValueOfString("a"))
+		grpc.SetHeader(ctx, metadata.Pairs("x-trace", "1"))
+		return out, nil
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "articles.v1.Articles",
+		HandlerType: (*interface{})(nil),
+		Methods:     []grpc.MethodDesc{{MethodName: "Get", Handler: handler}},
+	}, struct{}{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+func TestInvoke(t *testing.T) {
+	files := testDescriptors(t)
+	client := New(Options{Address: startServer(t, files), Plaintext: true, Descriptors: files})
+	defer client.Close()
+
+	auth := map[string][]string{"Authorization": {"Bearer abc"}}
+	tests := []struct {
+		method   string
+		headers  map[string][]string
+		payload  string
+		expected Response
+	}{
+		{"articles.v1.Articles/Get", auth, `{"id":"12"}`, Response{Code: 0, Body: []byte(`{"id":"12","title":"a"}`), Headers: map[string][]string{"x-trace": {"1"}}}},
+		{"/articles.v1.Articles/Get", auth, `{"id":"13"}`, Response{Code: 5, Message: "article 13 not found"}},
+		{"articles.v1.Articles/Get", map[string][]string{}, `{"id":"12"}`, Response{Code: 16, Message: "missing token"}},
+	}
+	for i, tt := range tests {
+		r, err := client.Invoke(tt.method, tt.headers, []byte(tt.payload), time.Second)
+		if err != nil {
+			t.Fatalf("%d failed %v", i, err)
+		}
+		delete(r.Headers, "content-type")
+		if len(r.Headers) == 0 {
+			r.Headers = nil
+		}
+		if r.Code != tt.expected.Code || r.Message != tt.expected.Message || !reflect.DeepEqual(r.Headers, tt.expected.Headers) {
+			t.Fatalf("%d failed got %#v exp %#v", i, r, tt.expected)
+		}
+		if (r.Body == nil) != (tt.expected.Body == nil) {
+			t.Fatalf("%d failed got %s exp %s", i, r.Body, tt.expected.Body)
+		}
+		if r.Body != nil {
+			var got, exp interface{}
+			json.Unmarshal(r.Body, &got)
+			json.Unmarshal(tt.expected.Body, &exp)
+			if !reflect.DeepEqual(got, exp) {
+				t.Fatalf("%d failed got %s exp %s", i, r.Body, tt.expected.Body)
+			}
+		}
+	}
+
+	_, err := client.Invoke("articles.v1.Articles/Delete", auth, nil, time.Second)
+	if !errors.Is(err, ErrUnknownMethod) {
+		t.Fatalf("failed got %v, exp %v", err, ErrUnknownMethod)
+	}
+	_, err = client.Invoke("articles.v1.Articles/Get", auth, []byte(`{"unknown":1}`), time.Second)
+	if !errors.Is(err, ErrInvalidPayload) {
+		t.Fatalf("failed got %v, exp %v", err, ErrInvalidPayload)
+	}
+}
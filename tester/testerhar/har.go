@@ -0,0 +1,350 @@
+package testerhar
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/madelyne-io/madelyne/tester/testerclient"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Pages   []Page  `json:"pages"`
+	Entries []Entry `json:"entries"`
+}
+
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Page struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Id              string      `json:"id"`
+	Title           string      `json:"title"`
+	PageTimings     PageTimings `json:"pageTimings"`
+}
+
+type PageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+}
+
+type Entry struct {
+	Pageref         string   `json:"pageref,omitempty"`
+	StartedDateTime string   `json:"startedDateTime"`
+	Time            float64  `json:"time"`
+	Request         Request  `json:"request"`
+	Response        Response `json:"response"`
+	Cache           struct{} `json:"cache"`
+	Timings         Timings  `json:"timings"`
+	Error           string   `json:"_error,omitempty"`
+}
+
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type Request struct {
+	Method      string      `json:"method"`
+	Url         string      `json:"url"`
+	HttpVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HttpVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type Timings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Recorder keeps every exchange going through the requesters it wraps, in a
+// HAR 1.2 log where each test is a page.
+type Recorder struct {
+	// mutex guards log, an event stream is recorded when the test closes it,
+	// from an other goroutine.
+	mutex sync.Mutex
+	log   Log
+	pages map[string]bool
+	now   func() time.Time
+}
+
+func New() *Recorder {
+	return &Recorder{
+		log: Log{
+			Version: "1.2",
+			Creator: Creator{Name: "madelyne", Version: "1"},
+			Pages:   []Page{},
+			Entries: []Entry{},
+		},
+		pages: map[string]bool{},
+		now:   time.Now,
+	}
+}
+
+func (rec *Recorder) Log() Log {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	out := rec.log
+	out.Entries = append([]Entry{}, rec.log.Entries...)
+	return out
+}
+
+func (rec *Recorder) Wrap(r testerclient.Requester) testerclient.Requester {
+	return &requester{
+		next:     r,
+		recorder: rec,
+	}
+}
+
+func (rec *Recorder) Write(filename string) error {
+	data, err := json.MarshalIndent(struct {
+		Log Log `json:"log"`
+	}{rec.Log()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Clean(filename), data, 0644)
+}
+
+type requester struct {
+	next     testerclient.Requester
+	recorder *Recorder
+}
+
+func (r *requester) Make(request testerclient.Request) (testerclient.Response, error) {
+	rec := r.recorder
+	var sent []byte
+	if request.Body != nil {
+		var err error
+		sent, err = ioutil.ReadAll(request.Body)
+		if err != nil {
+			return testerclient.Response{}, err
+		}
+		request.Body = bytes.NewReader(sent)
+	}
+
+	started := rec.now()
+	response, err := r.next.Make(request)
+	waited := rec.now()
+
+	var received []byte
+	var stream *streamBody
+	switch {
+	case err != nil || response.Body == nil:
+	case strings.HasPrefix(response.ContentType, "text/event-stream"):
+		// a stream may never end, it can't be read before the test
+		stream = &streamBody{ReadCloser: response.Body, recorder: rec}
+		response.Body = stream
+	default:
+		received, err = ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		response.Body = ioutil.NopCloser(bytes.NewReader(received))
+	}
+	finished := rec.now()
+
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	if request.Name != "" && !rec.pages[request.Name] {
+		rec.pages[request.Name] = true
+		rec.log.Pages = append(rec.log.Pages, Page{
+			StartedDateTime: started.Format(time.RFC3339Nano),
+			Id:              request.Name,
+			Title:           request.Name,
+		})
+	}
+
+	u := response.Url
+	if u == "" {
+		u = request.Url
+	}
+	entry := Entry{
+		Pageref:         request.Name,
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Time:            milliseconds(finished.Sub(started)),
+		Request:         buildRequest(request, u, sent),
+		Response:        buildResponse(response, received),
+		Timings: Timings{
+			Wait:    milliseconds(waited.Sub(started)),
+			Receive: milliseconds(finished.Sub(waited)),
+		},
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	rec.log.Entries = append(rec.log.Entries, entry)
+	if stream != nil {
+		stream.index = len(rec.log.Entries) - 1
+		stream.response = response
+	}
+	return response, err
+}
+
+// streamBody gives its entry the bytes of the event stream read by the test
+// when it is closed.
+type streamBody struct {
+	io.ReadCloser
+	recorder *Recorder
+	index    int
+	response testerclient.Response
+	// mutex guards read, the test closes the body while it is being read.
+	mutex sync.Mutex
+	read  bytes.Buffer
+}
+
+func (s *streamBody) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	s.mutex.Lock()
+	s.read.Write(p[:n])
+	s.mutex.Unlock()
+	return n, err
+}
+
+func (s *streamBody) Close() error {
+	s.mutex.Lock()
+	response := buildResponse(s.response, s.read.Bytes())
+	s.mutex.Unlock()
+	s.recorder.mutex.Lock()
+	s.recorder.log.Entries[s.index].Response = response
+	s.recorder.mutex.Unlock()
+	return s.ReadCloser.Close()
+}
+
+func buildRequest(request testerclient.Request, u string, body []byte) Request {
+	out := Request{
+		Method:      request.Method,
+		Url:         u,
+		HttpVersion: "HTTP/1.1",
+		Cookies:     []NameValue{},
+		Headers:     []NameValue{},
+		QueryString: []NameValue{},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	keys := make([]string, 0, len(request.Headers))
+	for k := range request.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range request.Headers[k] {
+			out.Headers = append(out.Headers, NameValue{Name: k, Value: v})
+		}
+	}
+
+	if p, err := url.Parse(u); err == nil {
+		query := p.Query()
+		qKeys := make([]string, 0, len(query))
+		for k := range query {
+			qKeys = append(qKeys, k)
+		}
+		sort.Strings(qKeys)
+		for _, k := range qKeys {
+			for _, v := range query[k] {
+				out.QueryString = append(out.QueryString, NameValue{Name: k, Value: v})
+			}
+		}
+	}
+
+	if body != nil {
+		out.PostData = &PostData{
+			MimeType: http.Header(request.Headers).Get("Content-Type"),
+		}
+		// the body is recorded as the test wrote it, before being compressed
+		if strings.EqualFold(http.Header(request.Headers).Get("Content-Encoding"), "gzip") {
+			if reader, err := gzip.NewReader(bytes.NewReader(body)); err == nil {
+				if data, err := ioutil.ReadAll(reader); err == nil {
+					body = data
+				}
+			}
+		}
+		if utf8.Valid(body) {
+			out.PostData.Text = string(body)
+		} else {
+			out.PostData.Text = base64.StdEncoding.EncodeToString(body)
+			out.PostData.Encoding = "base64"
+		}
+	}
+	return out
+}
+
+func buildResponse(response testerclient.Response, body []byte) Response {
+	out := Response{
+		Status:      response.StatusCode,
+		StatusText:  http.StatusText(response.StatusCode),
+		HttpVersion: "HTTP/1.1",
+		Cookies:     []NameValue{},
+		Headers:     []NameValue{},
+		Content: Content{
+			Size:     len(body),
+			MimeType: response.ContentType,
+		},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	keys := make([]string, 0, len(response.Headers))
+	for k := range response.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range response.Headers[k] {
+			out.Headers = append(out.Headers, NameValue{Name: k, Value: v})
+		}
+	}
+
+	if utf8.Valid(body) {
+		out.Content.Text = string(body)
+	} else {
+		out.Content.Text = base64.StdEncoding.EncodeToString(body)
+		out.Content.Encoding = "base64"
+	}
+	return out
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,288 @@
+package testerhar
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerclient"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeClient struct {
+	nextResponse testerclient.Response
+	nextError    error
+	lastBody     string
+}
+
+func (fc *fakeClient) Make(r testerclient.Request) (testerclient.Response, error) {
+	if r.Body != nil {
+		body, _ := ioutil.ReadAll(r.Body)
+		fc.lastBody = string(body)
+	}
+	return fc.nextResponse, fc.nextError
+}
+
+func fakeClock() func() time.Time {
+	current := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		current = current.Add(10 * time.Millisecond)
+		return current
+	}
+}
+
+func TestRecord(t *testing.T) {
+	tests := []struct {
+		request       testerclient.Request
+		response      testerclient.Response
+		responseBody  string
+		simulatedErr  error
+		expectedEntry Entry
+	}{
+		{
+			request: testerclient.Request{
+				Name:    "main/configs/tests.yml:POST:0",
+				Method:  "POST",
+				Url:     "/articles?b=2&a=1",
+				Headers: map[string][]string{"Content-Type": {"application/json"}, "Authorization": {"Bearer abc"}},
+				Body:    strings.NewReader(`{"a":1}`),
+			},
+			response: testerclient.Response{
+				Url:         "http://localhost:3000/articles?a=1&b=2",
+				StatusCode:  201,
+				ContentType: "application/json",
+				Headers:     map[string][]string{"Content-Type": {"application/json"}, "Set-Cookie": {"a=1", "b=2"}},
+			},
+			responseBody: `{"id":1}`,
+			expectedEntry: Entry{
+				Pageref:         "main/configs/tests.yml:POST:0",
+				StartedDateTime: "2020-01-01T00:00:00.01Z",
+				Time:            20,
+				Request: Request{
+					Method:      "POST",
+					Url:         "http://localhost:3000/articles?a=1&b=2",
+					HttpVersion: "HTTP/1.1",
+					Cookies:     []NameValue{},
+					Headers:     []NameValue{{"Authorization", "Bearer abc"}, {"Content-Type", "application/json"}},
+					QueryString: []NameValue{{"a", "1"}, {"b", "2"}},
+					PostData:    &PostData{MimeType: "application/json", Text: `{"a":1}`},
+					HeadersSize: -1,
+					BodySize:    7,
+				},
+				Response: Response{
+					Status:      201,
+					StatusText:  "Created",
+					HttpVersion: "HTTP/1.1",
+					Cookies:     []NameValue{},
+					Headers:     []NameValue{{"Content-Type", "application/json"}, {"Set-Cookie", "a=1"}, {"Set-Cookie", "b=2"}},
+					Content:     Content{Size: 8, MimeType: "application/json", Text: `{"id":1}`},
+					HeadersSize: -1,
+					BodySize:    8,
+				},
+				Timings: Timings{Wait: 10, Receive: 10},
+			},
+		},
+		{
+			request: testerclient.Request{
+				Name:    "main/configs/tests.yml:GET:0",
+				Method:  "GET",
+				Url:     "/file",
+				Headers: map[string][]string{},
+			},
+			response: testerclient.Response{
+				StatusCode:  200,
+				ContentType: "application/pdf",
+				Headers:     map[string][]string{},
+			},
+			responseBody: "\xff\xfe",
+			expectedEntry: Entry{
+				Pageref:         "main/configs/tests.yml:GET:0",
+				StartedDateTime: "2020-01-01T00:00:00.01Z",
+				Time:            20,
+				Request: Request{
+					Method:      "GET",
+					Url:         "/file",
+					HttpVersion: "HTTP/1.1",
+					Cookies:     []NameValue{},
+					Headers:     []NameValue{},
+					QueryString: []NameValue{},
+					HeadersSize: -1,
+				},
+				Response: Response{
+					Status:      200,
+					StatusText:  "OK",
+					HttpVersion: "HTTP/1.1",
+					Cookies:     []NameValue{},
+					Headers:     []NameValue{},
+					Content:     Content{Size: 2, MimeType: "application/pdf", Text: "//4=", Encoding: "base64"},
+					HeadersSize: -1,
+					BodySize:    2,
+				},
+				Timings: Timings{Wait: 10, Receive: 10},
+			},
+		},
+		{
+			request: testerclient.Request{
+				Method:  "GET",
+				Url:     "/down",
+				Headers: map[string][]string{},
+			},
+			simulatedErr: fmt.Errorf("connection refused"),
+			expectedEntry: Entry{
+				StartedDateTime: "2020-01-01T00:00:00.01Z",
+				Time:            20,
+				Request: Request{
+					Method:      "GET",
+					Url:         "/down",
+					HttpVersion: "HTTP/1.1",
+					Cookies:     []NameValue{},
+					Headers:     []NameValue{},
+					QueryString: []NameValue{},
+					HeadersSize: -1,
+				},
+				Response: Response{
+					HttpVersion: "HTTP/1.1",
+					Cookies:     []NameValue{},
+					Headers:     []NameValue{},
+					HeadersSize: -1,
+				},
+				Timings: Timings{Wait: 10, Receive: 10},
+				Error:   "connection refused",
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		rec := New()
+		rec.now = fakeClock()
+		if tt.responseBody != "" {
+			tt.response.Body = ioutil.NopCloser(strings.NewReader(tt.responseBody))
+		}
+		client := &fakeClient{nextResponse: tt.response, nextError: tt.simulatedErr}
+		r := rec.Wrap(client)
+
+		response, err := r.Make(tt.request)
+		if err != tt.simulatedErr {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.simulatedErr)
+		}
+		if tt.expectedEntry.Request.PostData != nil && client.lastBody != tt.expectedEntry.Request.PostData.Text {
+			t.Fatalf("%d failed body sent %s", i, client.lastBody)
+		}
+		if response.Body != nil {
+			body, _ := ioutil.ReadAll(response.Body)
+			if string(body) != tt.responseBody {
+				t.Fatalf("%d failed body should still be readable got %s", i, string(body))
+			}
+		}
+
+		log := rec.Log()
+		if len(log.Entries) != 1 {
+			t.Fatalf("%d failed got %d entries", i, len(log.Entries))
+		}
+		if !reflect.DeepEqual(log.Entries[0], tt.expectedEntry) {
+			t.Fatalf("%d failed \n exp %#v \n got %#v", i, tt.expectedEntry, log.Entries[0])
+		}
+		expectedPages := 0
+		if tt.request.Name != "" {
+			expectedPages = 1
+		}
+		if len(log.Pages) != expectedPages {
+			t.Fatalf("%d failed got %d pages", i, len(log.Pages))
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	rec := New()
+	r := rec.Wrap(&fakeClient{nextResponse: testerclient.Response{StatusCode: 204}})
+	for _, name := range []string{"a", "a", "b"} {
+		r.Make(testerclient.Request{Name: name, Method: "DELETE", Url: "/x"})
+	}
+
+	filename := filepath.Join(t.TempDir(), "out.har")
+	err := rec.Write(filename)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	har := struct {
+		Log Log `json:"log"`
+	}{}
+	err = json.Unmarshal(data, &har)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Pages) != 2 || len(har.Log.Entries) != 3 {
+		t.Fatalf("failed got %#v", har.Log)
+	}
+}
+
+func TestRecordPostData(t *testing.T) {
+	compressed := &bytes.Buffer{}
+	zw := gzip.NewWriter(compressed)
+	zw.Write([]byte(`{"a":1}`))
+	zw.Close()
+
+	tests := []struct {
+		headers  map[string][]string
+		body     []byte
+		expected PostData
+	}{
+		{
+			headers:  map[string][]string{"Content-Type": {"application/json"}, "Content-Encoding": {"gzip"}},
+			body:     compressed.Bytes(),
+			expected: PostData{MimeType: "application/json", Text: `{"a":1}`},
+		},
+		{
+			headers:  map[string][]string{"Content-Type": {"application/octet-stream"}},
+			body:     []byte("\xff\xfe"),
+			expected: PostData{MimeType: "application/octet-stream", Text: "//4=", Encoding: "base64"},
+		},
+	}
+
+	for i, tt := range tests {
+		recorder := New()
+		recorder.Wrap(&fakeClient{}).Make(testerclient.Request{Method: "POST", Url: "/articles", Headers: tt.headers, Body: bytes.NewReader(tt.body)})
+		request := recorder.Log().Entries[0].Request
+		if !reflect.DeepEqual(*request.PostData, tt.expected) || request.BodySize != len(tt.body) {
+			t.Fatalf("%d failed got %#v", i, request)
+		}
+	}
+}
+
+func TestRecordStream(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	go writer.Write([]byte("data: a\n\n"))
+
+	client := &fakeClient{nextResponse: testerclient.Response{
+		StatusCode:  200,
+		ContentType: "text/event-stream",
+		Body:        reader,
+	}}
+	recorder := New()
+	response, err := recorder.Wrap(client).Make(testerclient.Request{Name: "events", Method: "GET", Url: "/events"})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	data := make([]byte, 9)
+	_, err = io.ReadFull(response.Body, data)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	response.Body.Close()
+	content := recorder.Log().Entries[0].Response.Content
+	if content.Text != "data: a\n\n" || content.Size != 9 {
+		t.Fatalf("failed got %#v", content)
+	}
+}
@@ -0,0 +1,127 @@
+package testerinbox
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Inbox keeps what a server started for the tests receives, until it is
+// expected by a test or forgotten.
+type Inbox struct {
+	mutex sync.Mutex
+	items []interface{}
+	// notify is closed, then replaced, when an item is received.
+	notify chan struct{}
+}
+
+func New() *Inbox {
+	return &Inbox{
+		items:  []interface{}{},
+		notify: make(chan struct{}),
+	}
+}
+
+func (i *Inbox) Add(item interface{}) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.items = append(i.items, item)
+	close(i.notify)
+	i.notify = make(chan struct{})
+}
+
+// Items gives the items received and not expected yet, in order.
+func (i *Inbox) Items() []interface{} {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return append([]interface{}{}, i.items...)
+}
+
+// Reset forgets the items received.
+func (i *Inbox) Reset() {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.items = []interface{}{}
+}
+
+// Expect waits up to timeout for an item accepted by match. The item is then
+// forgotten, so it can't be expected twice. It is false when none came.
+func (i *Inbox) Expect(match func(item interface{}) bool, timeout time.Duration) (interface{}, bool) {
+	deadline := time.After(timeout)
+	for {
+		i.mutex.Lock()
+		for index, item := range i.items {
+			if match(item) {
+				i.items = append(i.items[:index], i.items[index+1:]...)
+				i.mutex.Unlock()
+				return item, true
+			}
+		}
+		notify := i.notify
+		i.mutex.Unlock()
+
+		select {
+		case <-notify:
+		case <-deadline:
+			return nil, false
+		}
+	}
+}
+
+// Request is a http request received by a server.
+type Request struct {
+	Method string
+	// Url is the request uri, with the query.
+	Url     string
+	Path    string
+	Headers map[string][]string
+	Body    []byte
+}
+
+// ReadRequest reads the body of r.
+func ReadRequest(r *http.Request) Request {
+	body, _ := ioutil.ReadAll(r.Body)
+	return Request{
+		Method:  r.Method,
+		Url:     r.URL.RequestURI(),
+		Path:    r.URL.Path,
+		Headers: r.Header,
+		Body:    body,
+	}
+}
+
+// Server serves handler on a port of localhost.
+type Server struct {
+	listener net.Listener
+	server   *http.Server
+}
+
+// Listen serves handler on the port, a free one when it is 0.
+func Listen(port int, handler http.Handler) (*Server, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		listener: listener,
+		server:   &http.Server{Handler: handler},
+	}
+	go s.server.Serve(listener)
+	return s, nil
+}
+
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Url gives the base url of the server.
+func (s *Server) Url() string {
+	return fmt.Sprintf("http://localhost:%d", s.Port())
+}
+
+func (s *Server) Close() error {
+	return s.server.Close()
+}
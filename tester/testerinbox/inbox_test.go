@@ -0,0 +1,34 @@
+package testerinbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpect(t *testing.T) {
+	inbox := New()
+	inbox.Add("a")
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		inbox.Add("b")
+	}()
+
+	isB := func(item interface{}) bool { return item == "b" }
+	item, ok := inbox.Expect(isB, time.Second)
+	if !ok || item != "b" {
+		t.Fatalf("failed got %v %v", item, ok)
+	}
+	_, ok = inbox.Expect(isB, 20*time.Millisecond)
+	if ok {
+		t.Fatalf("failed an item can't be expected twice")
+	}
+	items := inbox.Items()
+	if len(items) != 1 || items[0] != "a" {
+		t.Fatalf("failed got %v", items)
+	}
+
+	inbox.Reset()
+	if len(inbox.Items()) != 0 {
+		t.Fatalf("failed got %v", inbox.Items())
+	}
+}
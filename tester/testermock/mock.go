@@ -0,0 +1,236 @@
+package testermock
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/madelyne-io/madelyne/matcher"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"github.com/madelyne-io/madelyne/tester/unittester"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	captureRegexp = regexp.MustCompile(`^\#.*?\=\{\{(.*?)\}\}$`)
+	varRegexp     = regexp.MustCompile(`\#.*?\#`)
+)
+
+// Route is a response served for the method and path of a unit test.
+type Route struct {
+	Method string
+	// Path is the url of the test, whose `#var#` segments match any value.
+	Path        string
+	Status      int
+	ContentType string
+	Headers     map[string]string
+	Body        []byte
+	File        string
+
+	query    url.Values
+	segments []string
+}
+
+// Server answers the requests with the expected responses of a suite.
+type Server struct {
+	routes []Route
+}
+
+// New builds a route for each http test of the suite. GRAPHQL, GRPC, FILE,
+// event stream and websocket tests are not served.
+func New(config testerconfig.Config) *Server {
+	s := &Server{routes: []Route{}}
+	for _, name := range config.GroupsOrder {
+		group := config.Groups[name]
+		tests := group.UnitTests
+		for _, scenario := range group.ScenarioOrder {
+			tests = append(tests, group.Scenarios[scenario]...)
+		}
+		for _, ut := range tests {
+			if !ut.Replayable() || ut.Action == "GRAPHQL" || ut.Sse != nil {
+				continue
+			}
+			s.routes = append(s.routes, newRoute(ut, group.Environment))
+		}
+	}
+	return s
+}
+
+func newRoute(ut testerconfig.UnitTest, env map[string]string) Route {
+	rawUrl := unittester.ReplaceStringWithEnvValue(ut.Url, env)
+	path := rawUrl
+	query := url.Values{}
+	if i := strings.Index(rawUrl, "?"); i >= 0 {
+		path = rawUrl[:i]
+		query, _ = url.ParseQuery(rawUrl[i+1:])
+	}
+	for k, values := range ut.Query {
+		for _, v := range values {
+			query.Add(k, unittester.ReplaceStringWithEnvValue(v, env))
+		}
+	}
+
+	r := Route{
+		Method:      ut.Action,
+		Path:        path,
+		Status:      ut.Status,
+		ContentType: ut.CtOut,
+		Headers:     map[string]string{},
+		File:        ut.File,
+		query:       query,
+		segments:    splitPath(path),
+	}
+	for name, value := range ut.OutHeaders {
+		// the body is served decoded and its length computed again
+		if strings.EqualFold(name, "Content-Length") || strings.EqualFold(name, "Content-Encoding") {
+			continue
+		}
+		r.Headers[name] = fmt.Sprint(render(unittester.ReplaceStringWithEnvValue(value, env)))
+	}
+
+	out := unittester.ReplaceWithEnvValue(ut.Out, env)
+	var value interface{}
+	isJson := r.ContentType == "" || strings.Contains(r.ContentType, "json")
+	if isJson && len(out) > 0 && json.Unmarshal(out, &value) == nil {
+		r.Body, _ = json.Marshal(renderJson(value))
+		if r.ContentType == "" {
+			r.ContentType = "application/json"
+		}
+	} else if strings.HasPrefix(r.ContentType, "text/") {
+		r.Body = []byte(fmt.Sprint(render(string(out))))
+	} else {
+		r.Body = out
+	}
+	return r
+}
+
+// render gives a concrete value for a pattern, captured or not.
+func render(value string) interface{} {
+	if m := captureRegexp.FindStringSubmatch(value); m != nil {
+		value = m[1]
+	}
+	return matcher.Example(value)
+}
+
+func renderJson(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return render(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = renderJson(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = renderJson(v[k])
+		}
+	}
+	return value
+}
+
+// Routes gives the served routes, in the order of the suite.
+func (s *Server) Routes() []Route {
+	return s.routes
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	route := s.match(req.Method, req.URL.Path, req.URL.Query())
+	if route == nil {
+		s.notFound(w, req)
+		return
+	}
+	for name, value := range route.Headers {
+		w.Header().Set(name, value)
+	}
+	if route.ContentType != "" {
+		w.Header().Set("Content-Type", route.ContentType)
+	}
+	w.WriteHeader(route.Status)
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(route.Body)
+	}
+}
+
+// match gives the route with the most literal segments, then the most
+// matching and the fewest missing query parameters, then a successful status. The first route of the
+// suite wins a tie.
+func (s *Server) match(method string, path string, query url.Values) *Route {
+	segments := splitPath(path)
+	var best *Route
+	bestScore := 0
+	for i := range s.routes {
+		r := &s.routes[i]
+		if r.Method != method {
+			continue
+		}
+		score, ok := matchSegments(r.segments, segments)
+		if !ok {
+			continue
+		}
+		score *= 1000
+		for k, values := range r.query {
+			if len(values) > 0 && query.Get(k) == values[0] {
+				score += 10
+			} else {
+				score -= 10
+			}
+		}
+		if r.Status >= 200 && r.Status < 300 {
+			score++
+		}
+		if best == nil || score > bestScore {
+			best = r
+			bestScore = score
+		}
+	}
+	return best
+}
+
+func matchSegments(template []string, segments []string) (int, bool) {
+	if len(template) != len(segments) {
+		return 0, false
+	}
+	score := 0
+	for i, t := range template {
+		if varRegexp.MatchString(t) {
+			if segments[i] == "" {
+				return 0, false
+			}
+			continue
+		}
+		if t != segments[i] {
+			return 0, false
+		}
+		score++
+	}
+	return score, true
+}
+
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return []string{}
+	}
+	return strings.Split(p, "/")
+}
+
+func (s *Server) notFound(w http.ResponseWriter, req *http.Request) {
+	known := map[string]bool{}
+	for _, r := range s.routes {
+		known[r.Method+" "+r.Path] = true
+	}
+	routes := make([]string, 0, len(known))
+	for r := range known {
+		routes = append(routes, r)
+	}
+	sort.Strings(routes)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprintf(w, "No test matches %s %s\nKnown routes:\n", req.Method, req.URL.Path)
+	for _, r := range routes {
+		fmt.Fprintf(w, "  %s\n", r)
+	}
+}
@@ -0,0 +1,173 @@
+package testermock
+
+import (
+	"encoding/json"
+	"github.com/madelyne-io/madelyne/matcher"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testConfig() testerconfig.Config {
+	return testerconfig.Config{
+		GroupsOrder: []string{"articles"},
+		Groups: map[string]testerconfig.TestGroup{
+			"articles": {
+				Environment: map[string]string{"version": "v1"},
+				UnitTests: []testerconfig.UnitTest{
+					{
+						File:   "articles:bad_create",
+						Action: "POST",
+						Url:    "/#version#/articles",
+						Status: 400,
+						Out:    []byte(`{"error":"@string@"}`),
+					},
+					{
+						File:       "articles:create",
+						Action:     "POST",
+						Url:        "/#version#/articles",
+						Status:     201,
+						Out:        []byte(`{"id":"#article={{@uuid@}}","created":"@string@.isDateTime()","tags":"@array@.repeat('@string@')","count":"@integer@.greaterThan(10)"}`),
+						OutHeaders: map[string]string{"Location": "/v1/articles/@integer@", "Content-Length": "@integer@"},
+					},
+					{
+						File:   "articles:last",
+						Action: "GET",
+						Url:    "/#version#/articles?sort=desc",
+						Status: 200,
+						CtOut:  "text/plain",
+						Out:    []byte("last article is @integer@"),
+					},
+					{
+						File:   "articles:list",
+						Action: "GET",
+						Url:    "/#version#/articles",
+						Status: 200,
+						Out:    []byte(`[]`),
+					},
+					{
+						File:   "articles:upload",
+						Action: "FILE",
+						Url:    "/#version#/upload",
+					},
+				},
+				ScenarioOrder: []string{"read"},
+				Scenarios: map[string][]testerconfig.UnitTest{
+					"read": {
+						{
+							File:   "articles:read:1",
+							Action: "GET",
+							Url:    "/#version#/articles/#article#",
+							Status: 200,
+							CtOut:  "application/json",
+							Out:    []byte(`{"id":"#article#"}`),
+						},
+						{
+							File:   "articles:read:2",
+							Action: "GET",
+							Url:    "/#version#/articles/latest",
+							Status: 200,
+							CtOut:  "application/pdf",
+							Out:    []byte("%PDF @string@"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestServe(t *testing.T) {
+	s := New(testConfig())
+	if len(s.Routes()) != 6 {
+		t.Fatalf("got %d routes", len(s.Routes()))
+	}
+
+	tests := []struct {
+		method      string
+		url         string
+		status      int
+		contentType string
+		body        string
+	}{
+		{method: "GET", url: "/v1/articles", status: 200, contentType: "application/json", body: `[]`},
+		{method: "GET", url: "/v1/articles?sort=desc", status: 200, contentType: "text/plain", body: "last article is 1"},
+		{method: "GET", url: "/v1/articles/abc", status: 200, contentType: "application/json", body: `{"id":"#article#"}`},
+		{method: "GET", url: "/v1/articles/latest", status: 200, contentType: "application/pdf", body: "%PDF @string@"},
+		{method: "HEAD", url: "/v1/articles", status: 404},
+		{method: "PUT", url: "/v1/articles", status: 404},
+		{method: "GET", url: "/v1/upload", status: 404},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+		if w.Code != tt.status {
+			t.Fatalf("%d : got status %d", i, w.Code)
+		}
+		if tt.status == 404 {
+			continue
+		}
+		if w.Header().Get("Content-Type") != tt.contentType {
+			t.Fatalf("%d : got content type %s", i, w.Header().Get("Content-Type"))
+		}
+		if w.Body.String() != tt.body {
+			t.Fatalf("%d : got body %s", i, w.Body.String())
+		}
+	}
+}
+
+func TestServeRendersPatterns(t *testing.T) {
+	server := httptest.NewServer(New(testConfig()))
+	defer server.Close()
+
+	response, err := http.Post(server.URL+"/v1/articles", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != 201 {
+		t.Fatalf("the successful response must be preferred, got %d", response.StatusCode)
+	}
+	if response.Header.Get("Location") != "/v1/articles/1" {
+		t.Fatalf("got location %s", response.Header.Get("Location"))
+	}
+	data, _ := ioutil.ReadAll(response.Body)
+	body := map[string]interface{}{}
+	err = json.Unmarshal(data, &body)
+	if err != nil {
+		t.Fatalf("%v : %s", err, data)
+	}
+	expected := map[string]string{
+		"id":      "@uuid@",
+		"created": "@string@.isDateTime()",
+		"tags":    "@array@.repeat('@string@')",
+		"count":   "@integer@.greaterThan(10)",
+	}
+	for k, pattern := range expected {
+		err = matcher.Match(body[k], pattern)
+		if err != nil {
+			t.Fatalf("%s : %v does not match %s : %v", k, body[k], pattern, err)
+		}
+	}
+}
+
+func TestServeNotFound(t *testing.T) {
+	w := httptest.NewRecorder()
+	New(testConfig()).ServeHTTP(w, httptest.NewRequest("DELETE", "/v1/articles/1", nil))
+	if w.Code != 404 {
+		t.Fatalf("got status %d", w.Code)
+	}
+	expected := `No test matches DELETE /v1/articles/1
+Known routes:
+  GET /v1/articles
+  GET /v1/articles/#article#
+  GET /v1/articles/latest
+  POST /v1/articles
+`
+	if w.Body.String() != expected {
+		t.Fatalf("got \n%s\n exp \n%s", w.Body.String(), expected)
+	}
+}
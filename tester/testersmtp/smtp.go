@@ -0,0 +1,283 @@
+package testersmtp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerinbox"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoEmail = fmt.Errorf("No matching email received")
+)
+
+// Email is a message received by the server. From and To are the addresses of
+// the envelope, Text and Html the decoded parts of the body.
+type Email struct {
+	From    string
+	To      []string
+	Subject string
+	Headers map[string][]string
+	Text    string
+	Html    string
+	Raw     []byte
+}
+
+// Body gives the text part of the email, the html one when it has none.
+func (e Email) Body() string {
+	if e.Text != "" {
+		return e.Text
+	}
+	return e.Html
+}
+
+// Server is a SMTP server keeping the emails it receives until they are
+// expected by a test. Any authentication is accepted.
+type Server struct {
+	listener net.Listener
+	emails   *testerinbox.Inbox
+
+	mutex sync.Mutex
+	conns map[net.Conn]bool
+}
+
+// Start listens on the port, a free one when it is 0.
+func Start(port int) (*Server, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("cannot start smtp server : %w", err)
+	}
+	s := &Server{
+		listener: listener,
+		emails:   testerinbox.New(),
+		conns:    map[net.Conn]bool{},
+	}
+	go s.serve()
+	return s, nil
+}
+
+func (s *Server) Host() string {
+	return "localhost"
+}
+
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Expect waits up to timeout for an email accepted by match. The email is then
+// forgotten, so it can't be expected twice.
+func (s *Server) Expect(match func(e Email) bool, timeout time.Duration) (Email, error) {
+	e, ok := s.emails.Expect(func(item interface{}) bool {
+		return match(item.(Email))
+	}, timeout)
+	if !ok {
+		return Email{}, fmt.Errorf("%w in %s", ErrNoEmail, timeout)
+	}
+	return e.(Email), nil
+}
+
+// Pending gives the emails received and not expected yet.
+func (s *Server) Pending() []Email {
+	emails := []Email{}
+	for _, e := range s.emails.Items() {
+		emails = append(emails, e.(Email))
+	}
+	return emails
+}
+
+// Reset forgets the emails received.
+func (s *Server) Reset() {
+	s.emails.Reset()
+}
+
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for c := range s.conns {
+		c.Close()
+	}
+	return err
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		s.conns[conn] = true
+		s.mutex.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+		conn.Close()
+	}()
+	text := textproto.NewConn(conn)
+	reply := func(format string, args ...interface{}) bool {
+		return text.PrintfLine(format, args...) == nil
+	}
+
+	var from string
+	var to []string
+	if !reply("220 madelyne ESMTP") {
+		return
+	}
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		arg := strings.TrimSpace(line[len(verb):])
+		ok := true
+		switch verb {
+		case "EHLO":
+			ok = reply("250-madelyne") && reply("250-8BITMIME") && reply("250 AUTH PLAIN LOGIN")
+		case "HELO":
+			ok = reply("250 madelyne")
+		case "AUTH":
+			ok = s.auth(text, arg)
+		case "MAIL":
+			from = address(arg)
+			to = nil
+			ok = reply("250 OK")
+		case "RCPT":
+			to = append(to, address(arg))
+			ok = reply("250 OK")
+		case "DATA":
+			if len(to) == 0 {
+				ok = reply("503 RCPT first")
+				break
+			}
+			if !reply("354 End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			raw, err := ioutil.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			s.emails.Add(parseEmail(from, to, raw))
+			from, to = "", nil
+			ok = reply("250 OK")
+		case "RSET":
+			from, to = "", nil
+			ok = reply("250 OK")
+		case "NOOP":
+			ok = reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			ok = reply("502 Command not implemented")
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// auth accepts any credentials of the PLAIN and LOGIN mechanisms.
+func (s *Server) auth(text *textproto.Conn, arg string) bool {
+	parts := strings.Fields(arg)
+	if len(parts) == 0 {
+		return text.PrintfLine("501 Syntax error") == nil
+	}
+	challenges := 0
+	switch strings.ToUpper(parts[0]) {
+	case "PLAIN":
+		if len(parts) == 1 {
+			challenges = 1
+		}
+	case "LOGIN":
+		challenges = 2 - (len(parts) - 1)
+	default:
+		return text.PrintfLine("504 Unrecognized authentication type") == nil
+	}
+	for i := 0; i < challenges; i++ {
+		if text.PrintfLine("334 ") != nil {
+			return false
+		}
+		if _, err := text.ReadLine(); err != nil {
+			return false
+		}
+	}
+	return text.PrintfLine("235 Authentication succeeded") == nil
+}
+
+// address gives the address of a `FROM:<a@b.c> SIZE=12` argument.
+func address(arg string) string {
+	start := strings.Index(arg, "<")
+	end := strings.Index(arg, ">")
+	if start < 0 || end < start {
+		parts := strings.SplitN(arg, ":", 2)
+		return strings.TrimSpace(parts[len(parts)-1])
+	}
+	return arg[start+1 : end]
+}
+
+func parseEmail(from string, to []string, raw []byte) Email {
+	e := Email{From: from, To: to, Headers: map[string][]string{}, Raw: raw}
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		e.Text = string(raw)
+		return e
+	}
+	e.Headers = m.Header
+	e.Subject, err = new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		e.Subject = m.Header.Get("Subject")
+	}
+	readPart(&e, m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"), m.Body)
+	return e
+}
+
+// readPart sets the first text and html parts of the body.
+func readPart(e *Email, contentType string, encoding string, body io.Reader) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				return
+			}
+			readPart(e, part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+		}
+	}
+
+	switch strings.ToLower(encoding) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	data, _ := ioutil.ReadAll(body)
+	switch {
+	case mediaType == "text/plain" && e.Text == "":
+		e.Text = string(data)
+	case mediaType == "text/html" && e.Html == "":
+		e.Html = string(data)
+	}
+}
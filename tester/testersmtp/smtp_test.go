@@ -0,0 +1,95 @@
+package testersmtp
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReceive(t *testing.T) {
+	s, err := Start(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	addr := fmt.Sprintf("%s:%d", s.Host(), s.Port())
+
+	plain := "From: Acme <no-reply@acme.test>\r\nTo: jane@example.com\r\nSubject: =?UTF-8?Q?R=C3=A9initialisation?=\r\n\r\nHello,\r\n.reset with token=abc123\r\n"
+	err = smtp.SendMail(addr, smtp.PlainAuth("", "user", "password", s.Host()), "no-reply@acme.test", []string{"jane@example.com", "bob@example.com"}, []byte(plain))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multipart := strings.Join([]string{
+		"From: no-reply@acme.test",
+		"To: jane@example.com",
+		"Subject: Welcome",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/alternative; boundary="b1"`,
+		"",
+		"--b1",
+		"Content-Type: text/html; charset=utf-8",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"<p>Welcome Jane=2C</p>",
+		"--b1",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: base64",
+		"",
+		"V2VsY29tZSBK",
+		"YW5l",
+		"--b1--",
+		"",
+	}, "\r\n")
+	err = smtp.SendMail(addr, nil, "no-reply@acme.test", []string{"jane@example.com"}, []byte(multipart))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := s.Expect(func(e Email) bool { return e.Subject == "Welcome" }, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Text != "Welcome Jane" || e.Html != "<p>Welcome Jane,</p>" || e.Body() != "Welcome Jane" {
+		t.Fatalf("got %#v", e)
+	}
+
+	e, err = s.Expect(func(e Email) bool { return true }, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.From != "no-reply@acme.test" || strings.Join(e.To, ",") != "jane@example.com,bob@example.com" || e.Subject != "Réinitialisation" {
+		t.Fatalf("got %#v", e)
+	}
+	if e.Body() != "Hello,\n.reset with token=abc123\n" || e.Headers["From"][0] != "Acme <no-reply@acme.test>" {
+		t.Fatalf("got %q", e.Body())
+	}
+
+	_, err = s.Expect(func(e Email) bool { return true }, 10*time.Millisecond)
+	if !errors.Is(err, ErrNoEmail) {
+		t.Fatalf("an email can't be expected twice, got %v", err)
+	}
+}
+
+func TestPendingAndReset(t *testing.T) {
+	s, err := Start(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	addr := fmt.Sprintf("%s:%d", s.Host(), s.Port())
+	err = smtp.SendMail(addr, nil, "a@acme.test", []string{"b@acme.test"}, []byte("Subject: hi\r\n\r\nhi\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending := s.Pending(); len(pending) != 1 || pending[0].Subject != "hi" {
+		t.Fatalf("got %#v", pending)
+	}
+	s.Reset()
+	if len(s.Pending()) != 0 {
+		t.Fatalf("emails must be forgotten")
+	}
+}
@@ -0,0 +1,110 @@
+package testerstub
+
+import (
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"github.com/madelyne-io/madelyne/tester/testerinbox"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Call is a request received by a stub.
+type Call = testerinbox.Request
+
+// Server is a running stub, recording the calls it receives.
+type Server struct {
+	*testerinbox.Server
+	stub  testerconfig.Stub
+	calls *testerinbox.Inbox
+}
+
+// Start listens on the port of the stub, a free one when it is 0.
+func Start(stub testerconfig.Stub) (*Server, error) {
+	s := &Server{
+		stub:  stub,
+		calls: testerinbox.New(),
+	}
+	server, err := testerinbox.Listen(stub.Port, s)
+	if err != nil {
+		return nil, fmt.Errorf("cannot start stub %s : %w", stub.Name, err)
+	}
+	s.Server = server
+	return s, nil
+}
+
+// Calls gives the calls received since the last Reset, in order.
+func (s *Server) Calls() []Call {
+	calls := []Call{}
+	for _, c := range s.calls.Items() {
+		calls = append(calls, c.(Call))
+	}
+	return calls
+}
+
+// Reset forgets the calls received.
+func (s *Server) Reset() {
+	s.calls.Reset()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.calls.Add(testerinbox.ReadRequest(r))
+
+	route := s.match(r)
+	if route == nil {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "stub %s has no route for %s %s", s.stub.Name, r.Method, r.URL.RequestURI())
+		return
+	}
+
+	if route.Latency > 0 {
+		select {
+		case <-time.After(route.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if route.Error == "close" {
+		hijacker, ok := w.(http.Hijacker)
+		if ok {
+			conn, _, err := hijacker.Hijack()
+			if err == nil {
+				conn.Close()
+				return
+			}
+		}
+		panic(http.ErrAbortHandler)
+	}
+
+	for name, values := range route.Headers {
+		for _, v := range values {
+			w.Header().Add(name, v)
+		}
+	}
+	if route.CtOut != "" {
+		w.Header().Set("Content-Type", route.CtOut)
+	}
+	w.WriteHeader(route.Status)
+	_, _ = w.Write(route.Out)
+}
+
+func (s *Server) match(r *http.Request) *testerconfig.StubRoute {
+	for i, route := range s.stub.Routes {
+		if route.Method != r.Method {
+			continue
+		}
+		if MatchUrl(route.Url, r.URL.Path, r.URL.RequestURI()) {
+			return &s.stub.Routes[i]
+		}
+	}
+	return nil
+}
+
+// MatchUrl compares the expected url to the path or, when it has a query, to
+// the request uri.
+func MatchUrl(expected string, path string, uri string) bool {
+	if strings.Contains(expected, "?") {
+		return expected == uri
+	}
+	return expected == path
+}
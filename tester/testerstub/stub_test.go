@@ -0,0 +1,90 @@
+package testerstub
+
+import (
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer(t *testing.T) {
+	s, err := Start(testerconfig.Stub{
+		Name: "payments",
+		Routes: []testerconfig.StubRoute{
+			{Method: "POST", Url: "/charges", Status: 201, CtOut: "application/json", Headers: map[string][]string{"X-Stub": {"payments"}}, Out: []byte(`{"id":"ch_1"}`)},
+			{Method: "GET", Url: "/charges?slow=1", Status: 200, Latency: 100 * time.Millisecond},
+			{Method: "GET", Url: "/charges", Error: "close"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	r, err := http.Post(s.Url()+"/charges", "application/json", strings.NewReader(`{"amount":12}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if r.StatusCode != 201 || r.Header.Get("X-Stub") != "payments" || r.Header.Get("Content-Type") != "application/json" || string(body) != `{"id":"ch_1"}` {
+		t.Fatalf("got %d %v %s", r.StatusCode, r.Header, body)
+	}
+
+	start := time.Now()
+	r, err = http.Get(s.Url() + "/charges?slow=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != 200 || time.Since(start) < 100*time.Millisecond {
+		t.Fatalf("got %d in %s", r.StatusCode, time.Since(start))
+	}
+
+	_, err = http.Get(s.Url() + "/charges")
+	if err == nil {
+		t.Fatalf("the connection must be closed")
+	}
+
+	r, err = http.Get(s.Url() + "/refunds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != 404 {
+		t.Fatalf("got %d", r.StatusCode)
+	}
+
+	// the client may retry the request whose connection was closed
+	calls := s.Calls()
+	if len(calls) < 4 || calls[len(calls)-1].Path != "/refunds" {
+		t.Fatalf("got %#v", calls)
+	}
+	if calls[0].Method != "POST" || calls[0].Path != "/charges" || string(calls[0].Body) != `{"amount":12}` || calls[0].Headers["Content-Type"][0] != "application/json" {
+		t.Fatalf("got %#v", calls[0])
+	}
+	if calls[1].Url != "/charges?slow=1" || calls[1].Path != "/charges" {
+		t.Fatalf("got %#v", calls[1])
+	}
+
+	s.Reset()
+	if len(s.Calls()) != 0 {
+		t.Fatalf("calls must be forgotten")
+	}
+}
+
+func TestStartUsedPort(t *testing.T) {
+	s, err := Start(testerconfig.Stub{Name: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	other := testerconfig.Stub{Name: "second"}
+	other.Port = s.Port()
+	_, err = Start(other)
+	if err == nil || !strings.Contains(err.Error(), "second") {
+		t.Fatalf("got %v", err)
+	}
+}
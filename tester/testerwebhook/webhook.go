@@ -0,0 +1,64 @@
+package testerwebhook
+
+import (
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerinbox"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrNoCallback = fmt.Errorf("No matching callback received")
+)
+
+// Callback is a request received by the receiver.
+type Callback = testerinbox.Request
+
+// Receiver accepts any request as a callback and keeps it until it is
+// expected by a test.
+type Receiver struct {
+	*testerinbox.Server
+	callbacks *testerinbox.Inbox
+}
+
+// Start listens on the port, a free one when it is 0.
+func Start(port int) (*Receiver, error) {
+	r := &Receiver{callbacks: testerinbox.New()}
+	server, err := testerinbox.Listen(port, r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot start webhook receiver : %w", err)
+	}
+	r.Server = server
+	return r, nil
+}
+
+// Expect waits up to timeout for a callback accepted by match. The callback is
+// then forgotten, so it can't be expected twice.
+func (r *Receiver) Expect(match func(c Callback) bool, timeout time.Duration) (Callback, error) {
+	c, ok := r.callbacks.Expect(func(item interface{}) bool {
+		return match(item.(Callback))
+	}, timeout)
+	if !ok {
+		return Callback{}, fmt.Errorf("%w in %s", ErrNoCallback, timeout)
+	}
+	return c.(Callback), nil
+}
+
+// Pending gives the callbacks received and not expected yet.
+func (r *Receiver) Pending() []Callback {
+	callbacks := []Callback{}
+	for _, c := range r.callbacks.Items() {
+		callbacks = append(callbacks, c.(Callback))
+	}
+	return callbacks
+}
+
+// Reset forgets the callbacks received.
+func (r *Receiver) Reset() {
+	r.callbacks.Reset()
+}
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.callbacks.Add(testerinbox.ReadRequest(req))
+	w.WriteHeader(http.StatusNoContent)
+}
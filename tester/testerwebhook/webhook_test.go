@@ -0,0 +1,57 @@
+package testerwebhook
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpect(t *testing.T) {
+	r, err := Start(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	post := func(path string, body string) {
+		response, err := http.Post(r.Url()+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusNoContent {
+			t.Errorf("got status %d", response.StatusCode)
+		}
+	}
+
+	post("/orders", `{"id":1}`)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		post("/payments?attempt=2", `{"id":2}`)
+	}()
+
+	c, err := r.Expect(func(c Callback) bool { return c.Path == "/payments" }, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Method != "POST" || c.Url != "/payments?attempt=2" || string(c.Body) != `{"id":2}` || c.Headers["Content-Type"][0] != "application/json" {
+		t.Fatalf("got %#v", c)
+	}
+
+	_, err = r.Expect(func(c Callback) bool { return c.Path == "/payments" }, 50*time.Millisecond)
+	if !errors.Is(err, ErrNoCallback) {
+		t.Fatalf("a callback can't be expected twice, got %v", err)
+	}
+	if pending := r.Pending(); len(pending) != 1 || pending[0].Path != "/orders" {
+		t.Fatalf("got %#v", pending)
+	}
+
+	r.Reset()
+	_, err = r.Expect(func(c Callback) bool { return true }, 10*time.Millisecond)
+	if !errors.Is(err, ErrNoCallback) {
+		t.Fatalf("callbacks must be forgotten, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package unittester
+
+import (
+	"fmt"
+	"github.com/madelyne-io/madelyne/matcher"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"github.com/madelyne-io/madelyne/tester/testersmtp"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrNoSmtp         = fmt.Errorf("No smtp server started for the group")
+	ErrWrongEmail     = fmt.Errorf("Wrong email found")
+	ErrEmailNoCapture = fmt.Errorf("Value can't be captured from the email")
+)
+
+func (t *UnitTester) runEmail(ut testerconfig.UnitTest) error {
+	if t.Smtp == nil {
+		return ErrorIn(ut, nil, ErrNoSmtp)
+	}
+	timeout := ut.Timeout
+	if timeout == 0 {
+		timeout = testerconfig.DefaultTimeout
+	}
+	expected := testerconfig.EmailAssertion{}
+	if ut.Email != nil {
+		expected = *ut.Email
+	}
+
+	e, err := t.Smtp.Expect(func(e testersmtp.Email) bool {
+		if !t.matchEmailValue(e.Subject, expected.Subject) {
+			return false
+		}
+		for _, to := range e.To {
+			if t.matchEmailValue(to, expected.To) {
+				return true
+			}
+		}
+		return false
+	}, timeout)
+	if err != nil {
+		return ErrorIn(ut, emailsResult(t.Smtp.Pending()), fmt.Errorf("%w: to %s with subject %s", err, expected.To, expected.Subject))
+	}
+
+	if !t.matchEmailValue(e.From, expected.From) {
+		return ErrorIn(ut, e.Raw, fmt.Errorf("%w: from %s expected %s", ErrWrongEmail, e.From, expected.From))
+	}
+	body := e.Body()
+	if !t.matchEmailValue(body, expected.Body) {
+		return ErrorIn(ut, []byte(body), fmt.Errorf("%w: body does not match %s", ErrWrongEmail, expected.Body))
+	}
+	for name, expression := range expected.Capture {
+		found := regexp.MustCompile(expression).FindStringSubmatch(body)
+		if found == nil {
+			return ErrorIn(ut, []byte(body), fmt.Errorf("%w: %s does not match %s", ErrEmailNoCapture, name, expression))
+		}
+		value := found[0]
+		if len(found) > 1 {
+			value = found[1]
+		}
+		t.Environment[name] = value
+	}
+	return nil
+}
+
+// matchEmailValue matches the literal or matcher pattern, an empty one
+// matching any value.
+func (t *UnitTester) matchEmailValue(value string, expected string) bool {
+	if expected == "" {
+		return true
+	}
+	return matcher.Match(value, ReplaceStringWithEnvValue(expected, t.Environment)) == nil
+}
+
+func emailsResult(emails []testersmtp.Email) []byte {
+	lines := make([]string, 0, len(emails))
+	for _, e := range emails {
+		lines = append(lines, fmt.Sprintf("to %s : %s", strings.Join(e.To, ", "), e.Subject))
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
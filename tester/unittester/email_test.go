@@ -0,0 +1,86 @@
+package unittester
+
+import (
+	"errors"
+	"fmt"
+	"github.com/madelyne-io/madelyne/comparator"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"github.com/madelyne-io/madelyne/tester/testersmtp"
+	"net/smtp"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunSingleEmail(t *testing.T) {
+	server, err := testersmtp.Start(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	send := func(to string, subject string, body string) {
+		message := fmt.Sprintf("From: no-reply@acme.test\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n", to, subject, body)
+		err := smtp.SendMail(fmt.Sprintf("localhost:%d", server.Port()), nil, "no-reply@acme.test", []string{to}, []byte(message))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		email       *testerconfig.EmailAssertion
+		expected    error
+		expectedEnv map[string]string
+	}{
+		{
+			email: &testerconfig.EmailAssertion{
+				To:      "#email#",
+				From:    "@string@.endsWith('@acme.test')",
+				Subject: "@string@.contains('password')",
+				Body:    "@string@.contains('/reset?token=')",
+				Capture: map[string]string{"token": "token=([a-f0-9]+)", "link": "https://[^ ]+"},
+			},
+			expectedEnv: map[string]string{"email": "jane@example.com", "token": "4f2a", "link": "https://acme.test/reset?token=4f2a"},
+		},
+		{
+			email:    &testerconfig.EmailAssertion{To: "#email#", From: "support@acme.test"},
+			expected: ErrWrongEmail,
+		},
+		{
+			email:    &testerconfig.EmailAssertion{To: "#email#", Subject: "Welcome", Body: "@string@.contains('Hello')"},
+			expected: ErrWrongEmail,
+		},
+		{
+			email:    &testerconfig.EmailAssertion{Subject: "Welcome", Capture: map[string]string{"token": "token=([a-f0-9]+)"}},
+			expected: ErrEmailNoCapture,
+		},
+		{
+			email:    &testerconfig.EmailAssertion{To: "bob@example.com"},
+			expected: testersmtp.ErrNoEmail,
+		},
+		{
+			expectedEnv: map[string]string{"email": "jane@example.com"},
+		},
+	}
+
+	for i, tt := range tests {
+		send("jane@example.com", "Welcome", "Welcome Jane")
+		send("jane@example.com", "Reset your password", "Open https://acme.test/reset?token=4f2a to continue")
+		unittester := New(&fakeClient{}, comparator.New("."), &fakeFileOpener{})
+		unittester.Smtp = server
+		unittester.Env()["email"] = "jane@example.com"
+		err := unittester.RunSingle(testerconfig.UnitTest{Action: "EXPECT_EMAIL", Email: tt.email, Timeout: 50 * time.Millisecond})
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+		if tt.expectedEnv != nil && !reflect.DeepEqual(unittester.Env(), tt.expectedEnv) {
+			t.Fatalf("%d failed got %v, exp %v", i, unittester.Env(), tt.expectedEnv)
+		}
+		server.Reset()
+	}
+
+	unittester := New(&fakeClient{}, comparator.New("."), &fakeFileOpener{})
+	err = unittester.RunSingle(testerconfig.UnitTest{Action: "EXPECT_EMAIL"})
+	if !errors.Is(err, ErrNoSmtp) {
+		t.Fatalf("failed got %v", err)
+	}
+}
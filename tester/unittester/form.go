@@ -0,0 +1,51 @@
+package unittester
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// buildForm gives the multipart/form-data body of the form and its content
+// type, environment variables being replaced in the names and values of the
+// fields.
+func buildForm(form *testerconfig.Form, env map[string]string) ([]byte, string) {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+
+	fields := replaceValues(form.Fields, env)
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range fields[name] {
+			// writing in a bytes.Buffer can't fail
+			w.WriteField(name, value)
+		}
+	}
+
+	for _, f := range form.Files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(f.Name), quoteEscaper.Replace(f.Filename)))
+		header.Set("Content-Type", f.ContentType)
+		part, _ := w.CreatePart(header)
+		part.Write(f.Content)
+	}
+	w.Close()
+	return body.Bytes(), w.FormDataContentType()
+}
+
+// encodeFormUrlencoded gives the application/x-www-form-urlencoded body of the
+// values, environment variables being replaced before encoding.
+func encodeFormUrlencoded(values map[string][]string, env map[string]string) []byte {
+	return []byte(url.Values(replaceValues(values, env)).Encode())
+}
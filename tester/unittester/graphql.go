@@ -0,0 +1,74 @@
+package unittester
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"io"
+	"io/ioutil"
+)
+
+var (
+	ErrGraphqlErrors   = fmt.Errorf("GraphQL errors returned")
+	ErrGraphqlNoErrors = fmt.Errorf("GraphQL errors expected")
+)
+
+type graphqlResponse struct {
+	Data   interface{} `json:"data"`
+	Errors interface{} `json:"errors"`
+}
+
+// checkGraphql compares the data of a GraphQL response with Out and its errors
+// with OutErrors, both sharing the captures.
+func (t *UnitTester) checkGraphql(ut testerconfig.UnitTest, body io.Reader) *UnitTesterError {
+	if body == nil {
+		return ErrorIn(ut, nil, ErrRawBodyDontMatch)
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return ErrorIn(ut, nil, err)
+	}
+	response := graphqlResponse{}
+	err = json.Unmarshal(data, &response)
+	if err != nil {
+		return ErrorIn(ut, data, err)
+	}
+
+	t.comparator.Reset()
+	t.comparator.SetEnv(t.Env())
+	switch {
+	case ut.OutErrors == nil && response.Errors != nil:
+		return ErrorIn(ut, data, ErrGraphqlErrors)
+	case ut.OutErrors != nil && response.Errors == nil:
+		return ErrorIn(ut, data, ErrGraphqlNoErrors)
+	case ut.OutErrors != nil:
+		err = t.compareJson(response.Errors, ut.OutErrors)
+		if err != nil {
+			return ErrorIn(ut, data, fmt.Errorf("in errors %w", err))
+		}
+	}
+
+	if ut.Out != nil {
+		err = t.compareJson(response.Data, ut.Out)
+		if err != nil {
+			return ErrorIn(ut, data, fmt.Errorf("in data %w", err))
+		}
+	}
+
+	if ut.Pcre != "" {
+		err = t.comparator.Capture(data, ut.Pcre)
+		if err != nil {
+			return ErrorIn(ut, data, ErrPcreNoResult)
+		}
+	}
+	return nil
+}
+
+func (t *UnitTester) compareJson(actual interface{}, expected []byte) error {
+	var expectedData interface{}
+	err := json.Unmarshal(expected, &expectedData)
+	if err != nil {
+		return err
+	}
+	return t.comparator.Compare(actual, expectedData)
+}
@@ -0,0 +1,77 @@
+package unittester
+
+import (
+	"errors"
+	"github.com/madelyne-io/madelyne/comparator"
+	"github.com/madelyne-io/madelyne/matcher"
+	"github.com/madelyne-io/madelyne/tester/testerclient"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunSingleGraphql(t *testing.T) {
+	tests := []struct {
+		response    string
+		out         string
+		outErrors   string
+		expected    error
+		expectedEnv map[string]string
+	}{
+		{
+			response:    `{"data":{"article":{"id":12,"title":"a"}}}`,
+			out:         `{"article":{"id":"#id={{@integer@}}","title":"a"}}`,
+			expectedEnv: map[string]string{"id": "12"},
+		},
+		{
+			response: `{"data":{"article":{"id":12,"title":"b"}}}`,
+			out:      `{"article":{"id":"@integer@","title":"a"}}`,
+			expected: matcher.ErrInvalidValue,
+		},
+		{
+			response: `{"data":{"article":null},"errors":[{"message":"not found","path":["article"]}]}`,
+			out:      `{"article":null}`,
+			expected: ErrGraphqlErrors,
+		},
+		{
+			response:    `{"data":{"article":null},"errors":[{"message":"not found","path":["article"]}]}`,
+			out:         `{"article":null}`,
+			outErrors:   `[{"message":"#message={{@string@}}","path":["article"]}]`,
+			expectedEnv: map[string]string{"message": "not found"},
+		},
+		{
+			response:  `{"data":{"article":{"id":12,"title":"a"}}}`,
+			outErrors: `[{"message":"@string@"}]`,
+			expected:  ErrGraphqlNoErrors,
+		},
+	}
+
+	for i, tt := range tests {
+		response := testerclient.Response{
+			StatusCode:  200,
+			ContentType: "application/json",
+			Body:        ioutil.NopCloser(strings.NewReader(tt.response)),
+		}
+		client := &fakeClient{nexResponse: response}
+		unittester := New(client, comparator.New("."), &fakeFileOpener{})
+		ut := testerconfig.UnitTest{Action: "GRAPHQL", Url: "/graphql", Status: 200, CtIn: "application/json", Headers: map[string][]string{}, In: []byte(`{"query":"{ article { id } }"}`)}
+		if tt.out != "" {
+			ut.Out = []byte(tt.out)
+		}
+		if tt.outErrors != "" {
+			ut.OutErrors = []byte(tt.outErrors)
+		}
+		err := unittester.RunSingle(ut)
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+		if client.lastRequest.Method != "POST" {
+			t.Fatalf("%d failed got method %s", i, client.lastRequest.Method)
+		}
+		if err == nil && !reflect.DeepEqual(unittester.Env(), tt.expectedEnv) {
+			t.Fatalf("%d failed got %v, exp %v", i, unittester.Env(), tt.expectedEnv)
+		}
+	}
+}
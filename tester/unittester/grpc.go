@@ -0,0 +1,38 @@
+package unittester
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+)
+
+var (
+	ErrNoGrpc          = fmt.Errorf("GRPC tests need a `grpc` block in the configuration")
+	ErrWrongGrpcStatus = fmt.Errorf("Wrong grpc status found")
+)
+
+func (t *UnitTester) runGrpc(ut testerconfig.UnitTest) error {
+	if t.Grpc == nil {
+		return ErrorIn(ut, nil, ErrNoGrpc)
+	}
+	timeout := ut.Timeout
+	if timeout == 0 {
+		timeout = testerconfig.DefaultTimeout
+	}
+	r, err := t.Grpc.Invoke(ReplaceStringWithEnvValue(ut.Url, t.Environment), replaceValues(ut.Headers, t.Environment), ut.In, timeout)
+	if err != nil {
+		return ErrorIn(ut, nil, err)
+	}
+	if code := testerconfig.GrpcCodeName(r.Code); code != ut.GrpcStatus {
+		return ErrorIn(ut, r.Body, fmt.Errorf("%w: got %s expected %s : %s", ErrWrongGrpcStatus, code, ut.GrpcStatus, r.Message))
+	}
+
+	if ut.Out != nil {
+		utErr := t.compareBody(bytes.NewReader(r.Body), ut.Out, "application/json", ut.Pcre)
+		if utErr != nil {
+			utErr.Ut = ut
+			return utErr
+		}
+	}
+	return nil
+}
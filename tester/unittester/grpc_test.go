@@ -0,0 +1,102 @@
+package unittester
+
+import (
+	"errors"
+	"github.com/madelyne-io/madelyne/comparator"
+	"github.com/madelyne-io/madelyne/matcher"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"github.com/madelyne-io/madelyne/tester/testergrpc"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeInvoker struct {
+	nextResponse testergrpc.Response
+	nextError    error
+	lastMethod   string
+	lastHeaders  map[string][]string
+	lastPayload  []byte
+	lastTimeout  time.Duration
+}
+
+func (f *fakeInvoker) Invoke(method string, headers map[string][]string, payload []byte, timeout time.Duration) (testergrpc.Response, error) {
+	f.lastMethod = method
+	f.lastHeaders = headers
+	f.lastPayload = payload
+	f.lastTimeout = timeout
+	return f.nextResponse, f.nextError
+}
+
+func TestRunSingleGrpc(t *testing.T) {
+	tests := []struct {
+		response    testergrpc.Response
+		err         error
+		grpcStatus  string
+		out         string
+		expected    error
+		expectedEnv map[string]string
+	}{
+		{
+			response:    testergrpc.Response{Code: 0, Body: []byte(`{"id":"12","title":"a"}`)},
+			grpcStatus:  "OK",
+			out:         `{"id":"#article={{@string@}}","title":"a"}`,
+			expectedEnv: map[string]string{"id": "12", "token": "abc", "article": "12"},
+		},
+		{
+			response:   testergrpc.Response{Code: 0, Body: []byte(`{"id":"12","title":"b"}`)},
+			grpcStatus: "OK",
+			out:        `{"id":"@string@","title":"a"}`,
+			expected:   matcher.ErrInvalidValue,
+		},
+		{
+			response:    testergrpc.Response{Code: 5, Message: "article 12 not found"},
+			grpcStatus:  "NOT_FOUND",
+			expectedEnv: map[string]string{"id": "12", "token": "abc"},
+		},
+		{
+			response:   testergrpc.Response{Code: 16, Message: "missing token"},
+			grpcStatus: "OK",
+			expected:   ErrWrongGrpcStatus,
+		},
+		{
+			err:        testergrpc.ErrUnknownMethod,
+			grpcStatus: "OK",
+			expected:   testergrpc.ErrUnknownMethod,
+		},
+	}
+
+	for i, tt := range tests {
+		invoker := &fakeInvoker{nextResponse: tt.response, nextError: tt.err}
+		unittester := New(&fakeClient{}, comparator.New("."), &fakeFileOpener{})
+		unittester.Grpc = invoker
+		unittester.Env()["id"] = "12"
+		unittester.Env()["token"] = "abc"
+		ut := testerconfig.UnitTest{
+			Action:     "GRPC",
+			Url:        "articles.v1.Articles/Get",
+			Headers:    map[string][]string{"authorization": {"Bearer #token#"}},
+			In:         []byte(`{"id":"#id#"}`),
+			GrpcStatus: tt.grpcStatus,
+		}
+		if tt.out != "" {
+			ut.Out = []byte(tt.out)
+		}
+		err := unittester.RunSingle(ut)
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+		if string(invoker.lastPayload) != `{"id":"12"}` || invoker.lastHeaders["authorization"][0] != "Bearer abc" || invoker.lastTimeout != testerconfig.DefaultTimeout {
+			t.Fatalf("%d failed got %s %v %s", i, invoker.lastPayload, invoker.lastHeaders, invoker.lastTimeout)
+		}
+		if err == nil && !reflect.DeepEqual(unittester.Env(), tt.expectedEnv) {
+			t.Fatalf("%d failed got %v, exp %v", i, unittester.Env(), tt.expectedEnv)
+		}
+	}
+
+	unittester := New(&fakeClient{}, comparator.New("."), &fakeFileOpener{})
+	err := unittester.RunSingle(testerconfig.UnitTest{Action: "GRPC", Url: "articles.v1.Articles/Get"})
+	if !errors.Is(err, ErrNoGrpc) {
+		t.Fatalf("failed got %v, exp %v", err, ErrNoGrpc)
+	}
+}
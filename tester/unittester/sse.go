@@ -0,0 +1,153 @@
+package unittester
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/madelyne-io/madelyne/matcher"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissingEvents = fmt.Errorf("Missing events in the stream")
+	ErrWrongEvent    = fmt.Errorf("Wrong event found")
+)
+
+type sseEvent struct {
+	Event string
+	Id    string
+	Data  string
+}
+
+func (e sseEvent) String() string {
+	return fmt.Sprintf("event: %s, id: %s, data: %s", e.Event, e.Id, e.Data)
+}
+
+// readEvents reads the events of a text/event-stream body until count events
+// are received, the stream ends or timeout is reached. The body is closed to
+// stop a stream still open.
+func readEvents(body io.ReadCloser, count int, timeout time.Duration) []sseEvent {
+	events := make(chan sseEvent)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(events)
+		parseEvents(body, func(e sseEvent) bool {
+			select {
+			case events <- e:
+				return true
+			case <-done:
+				return false
+			}
+		})
+	}()
+
+	out := []sseEvent{}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for len(out) < count {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return out
+			}
+			out = append(out, e)
+		case <-timer.C:
+			body.Close()
+			return out
+		}
+	}
+	body.Close()
+	return out
+}
+
+// parseEvents gives the events of the stream to dispatch until it returns
+// false, following https://html.spec.whatwg.org/multipage/server-sent-events.html
+func parseEvents(r io.Reader, dispatch func(sseEvent) bool) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lastId := ""
+	current := sseEvent{}
+	data := []string{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				current.Id = lastId
+				current.Data = strings.Join(data, "\n")
+				if current.Event == "" {
+					current.Event = "message"
+				}
+				if !dispatch(current) {
+					return
+				}
+			}
+			current = sseEvent{}
+			data = []string{}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		value := ""
+		if len(parts) == 2 {
+			value = strings.TrimPrefix(parts[1], " ")
+		}
+		switch parts[0] {
+		case "event":
+			current.Event = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			lastId = value
+		}
+	}
+}
+
+// checkEvents reads the stream of the response and compares its events with
+// the expected ones, captures of their data being kept in the environment.
+func (t *UnitTester) checkEvents(ut testerconfig.UnitTest, body io.ReadCloser) *UnitTesterError {
+	if body == nil {
+		return ErrorIn(ut, nil, fmt.Errorf("%w: got 0 events expected %d", ErrMissingEvents, ut.Sse.Count))
+	}
+	events := readEvents(body, ut.Sse.Count, ut.Sse.Timeout)
+	if len(events) < ut.Sse.Count {
+		return ErrorIn(ut, eventsResult(events), fmt.Errorf("%w: got %d events expected %d in %s", ErrMissingEvents, len(events), ut.Sse.Count, ut.Sse.Timeout))
+	}
+
+	t.comparator.Reset()
+	t.comparator.SetEnv(t.Env())
+	for i, expected := range ut.Sse.Events {
+		got := events[i]
+		if expected.Event != "" && matcher.Match(got.Event, ReplaceStringWithEnvValue(expected.Event, t.Environment)) != nil {
+			return ErrorIn(ut, eventsResult(events), fmt.Errorf("%w: event %d type got %s expected %s", ErrWrongEvent, i, got.Event, expected.Event))
+		}
+		if expected.Id != "" && matcher.Match(got.Id, ReplaceStringWithEnvValue(expected.Id, t.Environment)) != nil {
+			return ErrorIn(ut, eventsResult(events), fmt.Errorf("%w: event %d id got %s expected %s", ErrWrongEvent, i, got.Id, expected.Id))
+		}
+		if expected.Data == nil {
+			continue
+		}
+		var data interface{}
+		if json.Unmarshal([]byte(got.Data), &data) != nil {
+			data = got.Data
+		}
+		err := t.comparator.Compare(data, expected.Data)
+		if err != nil {
+			return ErrorIn(ut, eventsResult(events), fmt.Errorf("%w: event %d data : %v", ErrWrongEvent, i, err))
+		}
+	}
+	return nil
+}
+
+func eventsResult(events []sseEvent) []byte {
+	lines := make([]string, 0, len(events))
+	for _, e := range events {
+		lines = append(lines, e.String())
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,156 @@
+package unittester
+
+import (
+	"errors"
+	"github.com/madelyne-io/madelyne/comparator"
+	"github.com/madelyne-io/madelyne/tester/testerclient"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"github.com/madelyne-io/madelyne/tester/testerhar"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testStream = `: comment
+
+event: created
+id: 1
+data: {"id": 12,
+data:  "title": "a"}
+
+data: ping
+
+id: 2
+event: deleted
+data: {"id": 12}
+
+`
+
+func TestReadEvents(t *testing.T) {
+	expected := []sseEvent{
+		{Event: "created", Id: "1", Data: "{\"id\": 12,\n \"title\": \"a\"}"},
+		{Event: "message", Id: "1", Data: "ping"},
+		{Event: "deleted", Id: "2", Data: `{"id": 12}`},
+	}
+
+	events := readEvents(ioutil.NopCloser(strings.NewReader(testStream)), 10, time.Second)
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("failed got %v exp %v", events, expected)
+	}
+
+	events = readEvents(ioutil.NopCloser(strings.NewReader(testStream)), 2, time.Second)
+	if !reflect.DeepEqual(events, expected[:2]) {
+		t.Fatalf("failed got %v exp %v", events, expected[:2])
+	}
+
+	r, w := io.Pipe()
+	go w.Write([]byte("data: first\n\n"))
+	events = readEvents(r, 2, 50*time.Millisecond)
+	if !reflect.DeepEqual(events, []sseEvent{{Event: "message", Data: "first"}}) {
+		t.Fatalf("failed got %v", events)
+	}
+}
+
+func TestRunSingleSse(t *testing.T) {
+	tests := []struct {
+		sse         testerconfig.SseAssertion
+		expected    error
+		expectedEnv map[string]string
+	}{
+		{
+			sse: testerconfig.SseAssertion{Count: 3, Timeout: time.Second, Events: []testerconfig.SseEvent{
+				{Event: "created", Id: "@integer@", Data: map[string]interface{}{"id": "#article={{@integer@}}", "title": "#title#"}},
+				{Data: "ping"},
+				{Event: "deleted", Data: map[string]interface{}{"id": "@integer@"}},
+			}},
+			expectedEnv: map[string]string{"title": "a", "article": "12"},
+		},
+		{
+			sse:         testerconfig.SseAssertion{Count: 1, Timeout: time.Second, Events: []testerconfig.SseEvent{{Event: "created"}}},
+			expectedEnv: map[string]string{"title": "a"},
+		},
+		{
+			sse:      testerconfig.SseAssertion{Count: 4, Timeout: time.Second, Events: []testerconfig.SseEvent{}},
+			expected: ErrMissingEvents,
+		},
+		{
+			sse:      testerconfig.SseAssertion{Count: 1, Timeout: time.Second, Events: []testerconfig.SseEvent{{Event: "deleted"}}},
+			expected: ErrWrongEvent,
+		},
+		{
+			sse:      testerconfig.SseAssertion{Count: 1, Timeout: time.Second, Events: []testerconfig.SseEvent{{Id: "2"}}},
+			expected: ErrWrongEvent,
+		},
+		{
+			sse:      testerconfig.SseAssertion{Count: 1, Timeout: time.Second, Events: []testerconfig.SseEvent{{Data: map[string]interface{}{"id": "@string@"}}}},
+			expected: ErrWrongEvent,
+		},
+	}
+
+	for i, tt := range tests {
+		response := testerclient.Response{
+			StatusCode:  200,
+			ContentType: "text/event-stream",
+			Body:        ioutil.NopCloser(strings.NewReader(testStream)),
+		}
+		unittester := New(&fakeClient{nexResponse: response}, comparator.New("."), &fakeFileOpener{})
+		unittester.Env()["title"] = "a"
+		sse := tt.sse
+		err := unittester.RunSingle(testerconfig.UnitTest{Action: "GET", Url: "/events", Status: 200, CtIn: "application/json", CtOut: "text/event-stream", Headers: map[string][]string{}, Sse: &sse})
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+		if err == nil && !reflect.DeepEqual(unittester.Env(), tt.expectedEnv) {
+			t.Fatalf("%d failed got %v, exp %v", i, unittester.Env(), tt.expectedEnv)
+		}
+	}
+}
+
+func TestSseWrongStatus(t *testing.T) {
+	// the stream never ends
+	reader, writer := io.Pipe()
+	defer writer.Close()
+
+	response := testerclient.Response{
+		StatusCode:  500,
+		ContentType: "text/event-stream",
+		Body:        reader,
+	}
+	unittester := New(&fakeClient{nexResponse: response}, comparator.New("."), &fakeFileOpener{})
+	sse := testerconfig.SseAssertion{Count: 1, Timeout: time.Second, Events: []testerconfig.SseEvent{}}
+	err := unittester.RunSingle(testerconfig.UnitTest{Action: "GET", Url: "/events", Status: 200, CtIn: "application/json", Headers: map[string][]string{}, Sse: &sse})
+	if !errors.Is(err, ErrWrongStatus) {
+		t.Fatalf("failed got %v, exp %v", err, ErrWrongStatus)
+	}
+}
+
+func TestRunSingleSseHar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: first\n\n"))
+		// the stream never ends, it is still read when the test times out
+		for r.Context().Err() == nil {
+			w.Write([]byte(": keep-alive\n"))
+			w.(http.Flusher).Flush()
+			time.Sleep(time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	recorder := testerhar.New()
+	unittester := New(recorder.Wrap(testerclient.New(server.URL)), comparator.New("."), &fakeFileOpener{})
+	sse := testerconfig.SseAssertion{Count: 2, Timeout: 50 * time.Millisecond, Events: []testerconfig.SseEvent{}}
+	err := unittester.RunSingle(testerconfig.UnitTest{Action: "GET", Url: "/events", Status: 200, CtIn: "application/json", CtOut: "text/event-stream", Headers: map[string][]string{}, Sse: &sse})
+	if !errors.Is(err, ErrMissingEvents) {
+		t.Fatalf("failed got %v, exp %v", err, ErrMissingEvents)
+	}
+	content := recorder.Log().Entries[0].Response.Content
+	if !strings.HasPrefix(content.Text, "data: first\n\n") {
+		t.Fatalf("failed got %#v", content)
+	}
+}
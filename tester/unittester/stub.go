@@ -0,0 +1,63 @@
+package unittester
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"github.com/madelyne-io/madelyne/tester/testerstub"
+	"strings"
+)
+
+var (
+	ErrNoStub         = fmt.Errorf("Stub is not started")
+	ErrWrongStubCalls = fmt.Errorf("Wrong number of stub calls")
+	ErrWrongStubBody  = fmt.Errorf("Wrong stub call body")
+)
+
+func (t *UnitTester) runStub(ut testerconfig.UnitTest) error {
+	a := ut.Stub
+	s, ok := t.Stubs[a.Name]
+	if !ok {
+		return ErrorIn(ut, nil, fmt.Errorf("%w: %s", ErrNoStub, a.Name))
+	}
+	u := ReplaceStringWithEnvValue(a.Url, t.Environment)
+	calls := []testerstub.Call{}
+	for _, c := range s.Calls() {
+		if a.Method != "" && c.Method != a.Method {
+			continue
+		}
+		if u != "" && !testerstub.MatchUrl(u, c.Path, c.Url) {
+			continue
+		}
+		calls = append(calls, c)
+	}
+
+	if a.Calls >= 0 && len(calls) != a.Calls {
+		return ErrorIn(ut, callsResult(calls), fmt.Errorf("%w: got %d expected %d", ErrWrongStubCalls, len(calls), a.Calls))
+	}
+	if len(calls) < len(a.Bodies) {
+		return ErrorIn(ut, callsResult(calls), fmt.Errorf("%w: got %d calls for %d bodies", ErrWrongStubCalls, len(calls), len(a.Bodies)))
+	}
+
+	t.comparator.Reset()
+	t.comparator.SetEnv(t.Env())
+	for i, expected := range a.Bodies {
+		var body interface{}
+		if json.Unmarshal(calls[i].Body, &body) != nil {
+			body = string(calls[i].Body)
+		}
+		err := t.comparator.Compare(body, expected)
+		if err != nil {
+			return ErrorIn(ut, callsResult(calls), fmt.Errorf("%w: call %d : %v", ErrWrongStubBody, i, err))
+		}
+	}
+	return nil
+}
+
+func callsResult(calls []testerstub.Call) []byte {
+	lines := make([]string, 0, len(calls))
+	for _, c := range calls {
+		lines = append(lines, fmt.Sprintf("%s %s\n%s", c.Method, c.Url, c.Body))
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
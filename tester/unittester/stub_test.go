@@ -0,0 +1,88 @@
+package unittester
+
+import (
+	"errors"
+	"github.com/madelyne-io/madelyne/comparator"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"github.com/madelyne-io/madelyne/tester/testerstub"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunSingleStub(t *testing.T) {
+	stub, err := testerstub.Start(testerconfig.Stub{
+		Name:   "payments",
+		Routes: []testerconfig.StubRoute{{Method: "POST", Url: "/charges", Status: 201}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stub.Close()
+	for _, body := range []string{`{"amount":12,"order":"o_1"}`, `{"amount":30,"order":"o_1"}`} {
+		r, err := http.Post(stub.Url()+"/charges?currency=eur", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Body.Close()
+	}
+	r, err := http.Get(stub.Url() + "/balance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+
+	tests := []struct {
+		stub        testerconfig.StubAssertion
+		expected    error
+		expectedEnv map[string]string
+	}{
+		{
+			stub: testerconfig.StubAssertion{Name: "payments", Calls: 3},
+		},
+		{
+			stub: testerconfig.StubAssertion{Name: "payments", Method: "POST", Url: "/charges", Calls: 2, Bodies: []interface{}{
+				map[string]interface{}{"amount": "#amount={{@integer@}}", "order": "#order#"},
+				map[string]interface{}{"amount": "@integer@.greaterThan(20)", "order": "#order#"},
+			}},
+			expectedEnv: map[string]string{"order": "o_1", "amount": "12"},
+		},
+		{
+			stub: testerconfig.StubAssertion{Name: "payments", Url: "/charges?currency=eur", Calls: 2},
+		},
+		{
+			stub: testerconfig.StubAssertion{Name: "payments", Url: "/charges?currency=usd", Calls: 0},
+		},
+		{
+			stub:     testerconfig.StubAssertion{Name: "payments", Method: "GET", Calls: 2},
+			expected: ErrWrongStubCalls,
+		},
+		{
+			stub:     testerconfig.StubAssertion{Name: "payments", Method: "GET", Calls: -1, Bodies: []interface{}{"a", "b"}},
+			expected: ErrWrongStubCalls,
+		},
+		{
+			stub:     testerconfig.StubAssertion{Name: "payments", Calls: -1, Bodies: []interface{}{map[string]interface{}{"amount": 30.0, "order": "@string@"}}},
+			expected: ErrWrongStubBody,
+		},
+		{
+			stub:     testerconfig.StubAssertion{Name: "mails", Calls: -1},
+			expected: ErrNoStub,
+		},
+	}
+
+	for i, tt := range tests {
+		unittester := New(&fakeClient{}, comparator.New("."), &fakeFileOpener{})
+		unittester.Stubs = map[string]*testerstub.Server{"payments": stub}
+		unittester.Env()["order"] = "o_1"
+		stubAssertion := tt.stub
+		err := unittester.RunSingle(testerconfig.UnitTest{Action: "STUB", Stub: &stubAssertion})
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+		if tt.expectedEnv != nil && !reflect.DeepEqual(unittester.Env(), tt.expectedEnv) {
+			t.Fatalf("%d failed got %v, exp %v", i, unittester.Env(), tt.expectedEnv)
+		}
+	}
+}
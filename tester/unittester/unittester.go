@@ -2,16 +2,29 @@ package unittester
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"github.com/madelyne-io/madelyne/comparator"
+	"github.com/madelyne-io/madelyne/matcher"
 	"github.com/madelyne-io/madelyne/tester/testerclient"
 	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"github.com/madelyne-io/madelyne/tester/testercurl"
 	"github.com/madelyne-io/madelyne/tester/testerfile"
+	"github.com/madelyne-io/madelyne/tester/testergrpc"
+	"github.com/madelyne-io/madelyne/tester/testersmtp"
+	"github.com/madelyne-io/madelyne/tester/testerstub"
+	"github.com/madelyne-io/madelyne/tester/testerwebhook"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -19,12 +32,22 @@ var (
 	ErrWrongContentType = fmt.Errorf("Wrong ContentType found")
 	ErrRawBodyDontMatch = fmt.Errorf("Wrong raw body found")
 	ErrPcreNoResult     = fmt.Errorf("No result found")
+	ErrBodylessResponse = fmt.Errorf("Response can't have a body")
+	ErrWrongHeader      = fmt.Errorf("Wrong header found")
+	ErrUnexpectedHeader = fmt.Errorf("Header should not be present")
+	ErrHeaderNoCapture  = fmt.Errorf("Header can't be captured")
+	ErrWrongCookie      = fmt.Errorf("Wrong cookie found")
+	ErrUnexpectedCookie = fmt.Errorf("Cookie should not be set")
+	ErrWrongTls         = fmt.Errorf("Wrong tls connection")
+	ErrWrongRedirects   = fmt.Errorf("Wrong redirects followed")
+	ErrWrongEncoding    = fmt.Errorf("Wrong Content-Encoding found")
 )
 
 type UnitTesterError struct {
 	Ut     testerconfig.UnitTest
 	Result []byte
 	Err    error
+	Curl   string
 }
 
 func ErrorIn(ut testerconfig.UnitTest, r []byte, err error) *UnitTesterError {
@@ -36,10 +59,14 @@ func ErrorIn(ut testerconfig.UnitTest, r []byte, err error) *UnitTesterError {
 }
 
 func (e *UnitTesterError) Error() string {
-	if e.Result == nil {
-		return fmt.Sprintf("in test :\nFile: %s\nUrl: %s\nIn: %s\nOut: %s\nCtOut: %s\nStatus: %d\nHeaders: %s\nErr: %s", e.Ut.File, e.Ut.Url, e.Ut.InName, e.Ut.OutName, e.Ut.CtOut, e.Ut.Status, e.Ut.Headers, e.Err.Error())
+	out := fmt.Sprintf("in test :\nFile: %s\nUrl: %s\nIn: %s\nOut: %s\nCtOut: %s\nStatus: %d\nHeaders: %s\nErr: %s", e.Ut.File, e.Ut.Url, e.Ut.InName, e.Ut.OutName, e.Ut.CtOut, e.Ut.Status, e.Ut.Headers, e.Err.Error())
+	if e.Result != nil {
+		out += fmt.Sprintf("\ngot : \n%s", e.Result)
 	}
-	return fmt.Sprintf("in test :\nFile: %s\nUrl: %s\nIn: %s\nOut: %s\nCtOut: %s\nStatus: %d\nHeaders: %s\nErr: %s\ngot : \n%s", e.Ut.File, e.Ut.Url, e.Ut.InName, e.Ut.OutName, e.Ut.CtOut, e.Ut.Status, e.Ut.Headers, e.Err.Error(), e.Result)
+	if e.Curl != "" {
+		out += fmt.Sprintf("\nReproduce with : \n%s", e.Curl)
+	}
+	return out
 }
 func (e *UnitTesterError) Unwrap() error { return e.Err }
 
@@ -48,6 +75,20 @@ type UnitTester struct {
 	comparator  comparator.Comparator
 	fileOpener  testerfile.FileOpener
 	Environment map[string]string
+	// Jar is the cookie jar of the scenario, nil when cookies are not kept.
+	Jar *testerclient.Jar
+	// Socket is the websocket of the scenario, nil out of scenarios.
+	Socket *testerclient.Socket
+	// Grpc calls the GRPC tests, nil when the suite has no grpc server.
+	Grpc testergrpc.Invoker
+	// Stubs are the running stubs of the group, by name.
+	Stubs map[string]*testerstub.Server
+	// Webhooks receives the callbacks of EXPECT_WEBHOOK steps, nil when not
+	// started.
+	Webhooks *testerwebhook.Receiver
+	// Smtp receives the emails of EXPECT_EMAIL steps, nil when the group has
+	// no smtp server.
+	Smtp *testersmtp.Server
 }
 
 func New(r testerclient.Requester, c comparator.Comparator, f testerfile.FileOpener) *UnitTester {
@@ -67,11 +108,24 @@ func (t *UnitTester) RunSingle(ut testerconfig.UnitTest) error {
 	if ut.In != nil {
 		ut.In = ReplaceWithEnvValue(ut.In, t.Environment)
 	}
+	if ut.ClearCookies && t.Jar != nil {
+		t.Jar.Clear()
+	}
 
 	var err error
 	switch ut.Action {
 	case "FILE":
 		err = t.runFile(ut)
+	case "WS_CONNECT", "WS_SEND", "WS_EXPECT", "WS_CLOSE":
+		err = t.runSocket(ut)
+	case "GRPC":
+		err = t.runGrpc(ut)
+	case "STUB":
+		err = t.runStub(ut)
+	case "EXPECT_WEBHOOK":
+		err = t.runWebhook(ut)
+	case "EXPECT_EMAIL":
+		err = t.runEmail(ut)
 	default:
 		err = t.runApi(ut)
 	}
@@ -86,51 +140,430 @@ func (t *UnitTester) RunSingle(ut testerconfig.UnitTest) error {
 	return nil
 }
 
-func (t *UnitTester) runApi(ut testerconfig.UnitTest) error {
+// BuildRequest gives the request sent for the test, its body must already be
+// substituted.
+func BuildRequest(ut testerconfig.UnitTest, env map[string]string) testerclient.Request {
+	body := ut.In
+	ctIn := ut.CtIn
+	if ut.Form != nil {
+		body, ctIn = buildForm(ut.Form, env)
+	}
+	if ut.FormUrlencoded != nil {
+		body = encodeFormUrlencoded(ut.FormUrlencoded, env)
+		ctIn = "application/x-www-form-urlencoded"
+	}
 	var sendedBody io.Reader
-	if ut.In != nil {
-		sendedBody = bytes.NewReader(ut.In)
+	if body != nil {
+		if ut.ContentEncoding == "gzip" {
+			body = gzipBody(body)
+		}
+		sendedBody = bytes.NewReader(body)
 	}
 	request := testerclient.Request{
-		Method:  ut.Action,
-		Url:     ReplaceStringWithEnvValue(ut.Url, t.Environment),
-		Body:    sendedBody,
-		Headers: map[string]string{"Content-Type": ut.CtIn},
+		Name:         ut.File,
+		Method:       ut.Method(),
+		Url:          addQuery(ReplaceStringWithEnvValue(ut.Url, env), ut.Query, env),
+		Body:         sendedBody,
+		Headers:      replaceValues(ut.Headers, env),
+		MaxRedirects: ut.MaxRedirects,
+	}
+
+	if ut.Form != nil {
+		// the boundary is chosen when the body is built
+		for name := range request.Headers {
+			if strings.EqualFold(name, "Content-Type") {
+				delete(request.Headers, name)
+			}
+		}
+	}
+	if _, ok := request.Headers["Content-Type"]; !ok {
+		request.Headers["Content-Type"] = []string{ctIn}
+	}
+	if _, ok := request.Headers["Accept-Encoding"]; !ok && ut.AcceptEncoding != "" {
+		request.Headers["Accept-Encoding"] = []string{ut.AcceptEncoding}
+	}
+	if _, ok := request.Headers["Accept"]; !ok && ut.Sse != nil {
+		request.Headers["Accept"] = []string{"text/event-stream"}
+	}
+	if ut.ContentEncoding != "" && body != nil {
+		request.Headers["Content-Encoding"] = []string{ut.ContentEncoding}
+	}
+	return request
+}
+
+func replaceValues(values map[string][]string, env map[string]string) map[string][]string {
+	out := map[string][]string{}
+	for key, list := range values {
+		key = ReplaceStringWithEnvValue(key, env)
+		for _, value := range list {
+			out[key] = append(out[key], ReplaceStringWithEnvValue(value, env))
+		}
 	}
+	return out
+}
 
-	for key, value := range ut.Headers {
-		request.Headers[key] = ReplaceStringWithEnvValue(value, t.Environment)
+// addQuery appends the query parameters of the test to the ones already
+// written in its url.
+func addQuery(u string, query map[string][]string, env map[string]string) string {
+	if len(query) == 0 {
+		return u
+	}
+	encoded := url.Values(replaceValues(query, env)).Encode()
+	if strings.Contains(u, "?") {
+		return u + "&" + encoded
 	}
+	return u + "?" + encoded
+}
 
+func (t *UnitTester) runApi(ut testerconfig.UnitTest) error {
+	request := BuildRequest(ut, t.Environment)
 	r, err := t.client.Make(request)
+	switch {
+	case err != nil || r.Body == nil:
+	case ut.Sse != nil:
+		// a stream may never end, it can't be drained
+		defer r.Body.Close()
+	default:
+		defer closeBody(r.Body)
+	}
+	utErr := t.checkResponse(ut, r, err)
+	if utErr != nil {
+		u := r.Url
+		if u == "" {
+			u = request.Url
+		}
+		utErr.Curl = CurlCommand(ut, request, u, t.Environment)
+		return utErr
+	}
+	return t.captureHeaders(ut, r)
+}
+
+// CurlCommand gives the curl invocation reproducing the request of the test,
+// sent to the url u.
+func CurlCommand(ut testerconfig.UnitTest, request testerclient.Request, u string, env map[string]string) string {
+	if ut.FormUrlencoded != nil {
+		return testercurl.Command(request.Method, u, request.Headers, encodeFormUrlencoded(ut.FormUrlencoded, env), "")
+	}
+	if ut.Form == nil {
+		return testercurl.Command(request.Method, u, request.Headers, ut.In, ut.InFile)
+	}
+	files := make([]testercurl.FormFile, 0, len(ut.Form.Files))
+	for _, f := range ut.Form.Files {
+		files = append(files, testercurl.FormFile{Name: f.Name, Path: f.Path, Filename: f.Filename, ContentType: f.ContentType})
+	}
+	return testercurl.FormCommand(request.Method, u, request.Headers, replaceValues(ut.Form.Fields, env), files)
+}
+
+// maxDrain is the most read by closeBody, like net/http does, as the body may
+// be an endless stream.
+const maxDrain = 64 << 10
+
+// closeBody reads what is left of the body so the connection can be reused.
+func closeBody(body io.ReadCloser) {
+	io.CopyN(ioutil.Discard, body, maxDrain)
+	body.Close()
+}
+
+// captureHeaders sets the captured headers in the environment, the first group
+// of the regexp being kept when it has one.
+func (t *UnitTester) captureHeaders(ut testerconfig.UnitTest, r testerclient.Response) error {
+	for name, c := range ut.CaptureHeaders {
+		value := http.Header(r.Headers).Get(c.Header)
+		if value == "" {
+			return ErrorIn(ut, nil, fmt.Errorf("%w: %s is missing", ErrHeaderNoCapture, c.Header))
+		}
+		if c.Regexp != "" {
+			found := regexp.MustCompile(c.Regexp).FindStringSubmatch(value)
+			if found == nil {
+				return ErrorIn(ut, nil, fmt.Errorf("%w: %s `%s` does not match %s", ErrHeaderNoCapture, c.Header, value, c.Regexp))
+			}
+			value = found[0]
+			if len(found) > 1 {
+				value = found[1]
+			}
+		}
+		t.Environment[name] = value
+	}
+	return nil
+}
+
+func (t *UnitTester) checkResponse(ut testerconfig.UnitTest, r testerclient.Response, err error) *UnitTesterError {
 	if err != nil {
 		return ErrorIn(ut, nil, fmt.Errorf("Error while requesting : %w", err))
 	}
 
 	if r.StatusCode != ut.Status {
-		return ErrorIn(ut, nil, fmt.Errorf("%w: got %d expected %d.\nRsp: \n%s", ErrWrongStatus, r.StatusCode, ut.Status, getResponseBody(r)))
+		return ErrorIn(ut, nil, fmt.Errorf("%w: got %d expected %d.\nRsp: \n%s", ErrWrongStatus, r.StatusCode, ut.Status, getResponseBody(ut, r)))
 	}
 
 	if ut.CtOut != "" && !strings.HasPrefix(r.ContentType, ut.CtOut) {
-		return ErrorIn(ut, nil, fmt.Errorf("%w: %s expected %s.\nRsp: \n%s", ErrWrongContentType, r.ContentType, ut.CtOut, getResponseBody(r)))
+		return ErrorIn(ut, nil, fmt.Errorf("%w: %s expected %s.\nRsp: \n%s", ErrWrongContentType, r.ContentType, ut.CtOut, getResponseBody(ut, r)))
+	}
+
+	utErr := checkHeaders(ut, r, t.Environment)
+	if utErr != nil {
+		return utErr
+	}
+
+	utErr = checkCookies(ut, r, t.Environment, time.Now())
+	if utErr != nil {
+		return utErr
+	}
+
+	utErr = checkTls(ut, r, t.Environment)
+	if utErr != nil {
+		return utErr
+	}
+
+	utErr = checkRedirects(ut, r, t.Environment)
+	if utErr != nil {
+		return utErr
+	}
+
+	utErr = checkEncoding(ut, r)
+	if utErr != nil {
+		return utErr
+	}
+
+	if ut.Action == "GRAPHQL" {
+		return t.checkGraphql(ut, r.Body)
 	}
 
 	if ut.Out != nil {
+		if isBodyless(ut.Action, r.StatusCode) {
+			return ErrorIn(ut, nil, fmt.Errorf("%w: %s with status %d, remove `out` from the test", ErrBodylessResponse, ut.Action, r.StatusCode))
+		}
+
 		ctOut := ut.CtOut
 		if ctOut == "" {
 			ctOut = r.ContentType
 		}
 
-		utErr := t.compareBody(r.Body, ut.Out, ctOut, ut.Pcre)
+		utErr = t.compareBody(r.Body, ut.Out, ctOut, ut.Pcre)
 		if utErr != nil {
 			utErr.Ut = ut
 			return utErr
 		}
 	}
 
+	if ut.Sse != nil {
+		return t.checkEvents(ut, r.Body)
+	}
+
 	return nil
 }
 
+// checkHeaders checks the outHeaders of the test, one of the values of a
+// header must match its literal or matcher pattern.
+func checkHeaders(ut testerconfig.UnitTest, r testerclient.Response, env map[string]string) *UnitTesterError {
+	headers := http.Header(r.Headers)
+	names := make([]string, 0, len(ut.OutHeaders))
+	for name := range ut.OutHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		expected := ReplaceStringWithEnvValue(ut.OutHeaders[name], env)
+		values := headers.Values(name)
+		if len(values) == 0 {
+			return ErrorIn(ut, nil, fmt.Errorf("%w: %s is missing, expected %s", ErrWrongHeader, name, expected))
+		}
+		matched := false
+		for _, v := range values {
+			if matcher.Match(v, expected) == nil {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return ErrorIn(ut, nil, fmt.Errorf("%w: %s got %s expected %s", ErrWrongHeader, name, strings.Join(values, ", "), expected))
+		}
+	}
+
+	for _, name := range ut.AbsentHeaders {
+		if values := headers.Values(name); len(values) > 0 {
+			return ErrorIn(ut, nil, fmt.Errorf("%w: %s got %s", ErrUnexpectedHeader, name, strings.Join(values, ", ")))
+		}
+	}
+	return nil
+}
+
+// checkCookies checks the cookies set by the response against the outCookies
+// of the test.
+func checkCookies(ut testerconfig.UnitTest, r testerclient.Response, env map[string]string, now time.Time) *UnitTesterError {
+	cookies := map[string]*http.Cookie{}
+	for _, c := range (&http.Response{Header: http.Header(r.Headers)}).Cookies() {
+		cookies[c.Name] = c
+	}
+	names := make([]string, 0, len(ut.OutCookies))
+	for name := range ut.OutCookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		expected := ut.OutCookies[name]
+		c, ok := cookies[name]
+		if !ok {
+			return ErrorIn(ut, nil, fmt.Errorf("%w: %s is not set", ErrWrongCookie, name))
+		}
+		err := checkCookie(c, expected, env, now)
+		if err != nil {
+			return ErrorIn(ut, nil, fmt.Errorf("%w: %s %v", ErrWrongCookie, name, err))
+		}
+	}
+
+	for _, name := range ut.AbsentCookies {
+		if c, ok := cookies[name]; ok {
+			return ErrorIn(ut, nil, fmt.Errorf("%w: %s got %s", ErrUnexpectedCookie, name, c.Value))
+		}
+	}
+	return nil
+}
+
+func checkCookie(c *http.Cookie, expected testerconfig.CookieAssertion, env map[string]string, now time.Time) error {
+	if expected.Value != "" {
+		pattern := ReplaceStringWithEnvValue(expected.Value, env)
+		if matcher.Match(c.Value, pattern) != nil {
+			return fmt.Errorf("value got %s expected %s", c.Value, pattern)
+		}
+	}
+	if expected.HttpOnly != nil && c.HttpOnly != *expected.HttpOnly {
+		return fmt.Errorf("httpOnly got %t expected %t", c.HttpOnly, *expected.HttpOnly)
+	}
+	if expected.Secure != nil && c.Secure != *expected.Secure {
+		return fmt.Errorf("secure got %t expected %t", c.Secure, *expected.Secure)
+	}
+	if expected.SameSite != "" && !strings.EqualFold(sameSite(c.SameSite), expected.SameSite) {
+		return fmt.Errorf("sameSite got %s expected %s", sameSite(c.SameSite), expected.SameSite)
+	}
+	if expected.Expires != "" {
+		lifetime := cookieLifetime(c, now)
+		if expected.Expires == "session" || lifetime == "session" {
+			if lifetime != expected.Expires {
+				return fmt.Errorf("expires got %s expected %s", lifetime, expected.Expires)
+			}
+			return nil
+		}
+		if matcher.Match(lifetime, expected.Expires) != nil {
+			return fmt.Errorf("expires in %s seconds expected %s", lifetime, expected.Expires)
+		}
+	}
+	return nil
+}
+
+func sameSite(s http.SameSite) string {
+	switch s {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
+
+// cookieLifetime gives the remaining seconds before the cookie expires, 0 when
+// it is deleted, or `session`.
+func cookieLifetime(c *http.Cookie, now time.Time) string {
+	switch {
+	case c.MaxAge > 0:
+		return strconv.Itoa(c.MaxAge)
+	case c.MaxAge < 0:
+		return "0"
+	case !c.Expires.IsZero():
+		seconds := int(c.Expires.Sub(now).Seconds())
+		if seconds < 0 {
+			seconds = 0
+		}
+		return strconv.Itoa(seconds)
+	}
+	return "session"
+}
+
+func checkTls(ut testerconfig.UnitTest, r testerclient.Response, env map[string]string) *UnitTesterError {
+	if ut.OutTls == nil {
+		return nil
+	}
+	if r.TLS == nil {
+		return ErrorIn(ut, nil, fmt.Errorf("%w: the connection is not encrypted", ErrWrongTls))
+	}
+	if ut.OutTls.Version != "" && testerconfig.TlsVersionName(r.TLS.Version) != ut.OutTls.Version {
+		return ErrorIn(ut, nil, fmt.Errorf("%w: version got %s expected %s", ErrWrongTls, testerconfig.TlsVersionName(r.TLS.Version), ut.OutTls.Version))
+	}
+	if ut.OutTls.Subject != "" {
+		if len(r.TLS.PeerCertificates) == 0 {
+			return ErrorIn(ut, nil, fmt.Errorf("%w: no peer certificate", ErrWrongTls))
+		}
+		subject := r.TLS.PeerCertificates[0].Subject.String()
+		pattern := ReplaceStringWithEnvValue(ut.OutTls.Subject, env)
+		if matcher.Match(subject, pattern) != nil {
+			return ErrorIn(ut, nil, fmt.Errorf("%w: subject got %s expected %s", ErrWrongTls, subject, pattern))
+		}
+	}
+	return nil
+}
+
+func checkRedirects(ut testerconfig.UnitTest, r testerclient.Response, env map[string]string) *UnitTesterError {
+	if ut.OutRedirects == nil {
+		return nil
+	}
+	chain := make([]string, 0, len(r.Redirects))
+	for _, redirect := range r.Redirects {
+		chain = append(chain, fmt.Sprintf("%d %s", redirect.StatusCode, redirect.Location))
+	}
+	if len(r.Redirects) != len(ut.OutRedirects) {
+		return ErrorIn(ut, nil, fmt.Errorf("%w: got %d redirects expected %d : [%s]", ErrWrongRedirects, len(r.Redirects), len(ut.OutRedirects), strings.Join(chain, ", ")))
+	}
+	for i, expected := range ut.OutRedirects {
+		got := r.Redirects[i]
+		if expected.Status != 0 && got.StatusCode != expected.Status {
+			return ErrorIn(ut, nil, fmt.Errorf("%w: redirect %d status got %d expected %d : [%s]", ErrWrongRedirects, i, got.StatusCode, expected.Status, strings.Join(chain, ", ")))
+		}
+		if expected.Location == "" {
+			continue
+		}
+		pattern := ReplaceStringWithEnvValue(expected.Location, env)
+		if matcher.Match(got.Location, pattern) != nil {
+			return ErrorIn(ut, nil, fmt.Errorf("%w: redirect %d location got %s expected %s : [%s]", ErrWrongRedirects, i, got.Location, pattern, strings.Join(chain, ", ")))
+		}
+	}
+	return nil
+}
+
+// checkEncoding checks the Content-Encoding of the response, identity meaning
+// that the body is not encoded.
+func checkEncoding(ut testerconfig.UnitTest, r testerclient.Response) *UnitTesterError {
+	if ut.OutEncoding == "" {
+		return nil
+	}
+	encoding := http.Header(r.Headers).Get("Content-Encoding")
+	if encoding == "" {
+		encoding = "identity"
+	}
+	if !strings.EqualFold(encoding, ut.OutEncoding) {
+		return ErrorIn(ut, nil, fmt.Errorf("%w: got %s expected %s", ErrWrongEncoding, encoding, ut.OutEncoding))
+	}
+	return nil
+}
+
+// gzipBody compresses the body sent.
+func gzipBody(body []byte) []byte {
+	out := &bytes.Buffer{}
+	w := gzip.NewWriter(out)
+	// writing in a bytes.Buffer can't fail
+	w.Write(body)
+	w.Close()
+	return out.Bytes()
+}
+
+// isBodyless tells if the response of a request can't have a body (RFC 7230 3.3.3).
+func isBodyless(method string, status int) bool {
+	return method == "HEAD" || (status >= 100 && status < 200) || status == 204 || status == 304
+}
+
 func (t *UnitTester) runFile(ut testerconfig.UnitTest) error {
 	ctOut := ut.CtOut
 	if ut.CtOut == "" && strings.Contains(ut.InName, ".json") {
@@ -197,9 +630,13 @@ func (t *UnitTester) compareBody(left io.Reader, right []byte, expectedContentTy
 	return nil
 }
 
-func getResponseBody(r testerclient.Response) string {
+func getResponseBody(ut testerconfig.UnitTest, r testerclient.Response) string {
+	if ut.Sse != nil {
+		// a stream may never end, it can't be read
+		return ""
+	}
 	var bodyBytes []byte
-	if r.Body != nil {
+	if r.Body != nil && !isBodyless(ut.Action, r.StatusCode) {
 		bodyBytes, _ = ioutil.ReadAll(r.Body)
 
 		if r.ContentType == "application/json" {
@@ -1,12 +1,22 @@
 package unittester
 
 import (
+	"compress/gzip"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"errors"
 	"fmt"
+	"github.com/madelyne-io/madelyne/comparator"
 	"github.com/madelyne-io/madelyne/tester/testerclient"
 	"github.com/madelyne-io/madelyne/tester/testerconfig"
 	"io"
 	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
@@ -68,7 +78,7 @@ func TestRunSingle(t *testing.T) {
 				Action:  "GET",
 				Url:     "/test",
 				Status:  200,
-				Headers: map[string]string{},
+				Headers: map[string][]string{},
 				In:      nil,
 				Out:     nil,
 				CtIn:    "application/json",
@@ -91,7 +101,7 @@ func TestRunSingle(t *testing.T) {
 				Action:  "GET",
 				Url:     "/test",
 				Status:  200,
-				Headers: map[string]string{"test": "value"},
+				Headers: map[string][]string{"test": {"value"}},
 				In:      nil,
 				Out:     nil,
 				CtIn:    "application/json",
@@ -114,7 +124,7 @@ func TestRunSingle(t *testing.T) {
 				Action:  "GET",
 				Url:     "/test",
 				Status:  200,
-				Headers: map[string]string{"test": "value"},
+				Headers: map[string][]string{"test": {"value"}},
 				In:      nil,
 				Out:     nil,
 				CtIn:    "application/json",
@@ -137,7 +147,7 @@ func TestRunSingle(t *testing.T) {
 				Action:  "GET",
 				Url:     "/test",
 				Status:  200,
-				Headers: map[string]string{"test": "value"},
+				Headers: map[string][]string{"test": {"value"}},
 				In:      nil,
 				Out:     nil,
 				CtIn:    "application/json",
@@ -160,7 +170,7 @@ func TestRunSingle(t *testing.T) {
 				Action:  "GET",
 				Url:     "/test",
 				Status:  200,
-				Headers: map[string]string{"test": "value"},
+				Headers: map[string][]string{"test": {"value"}},
 				In:      nil,
 				Out:     nil,
 				CtIn:    "application/json",
@@ -183,7 +193,7 @@ func TestRunSingle(t *testing.T) {
 				Action:  "GET",
 				Url:     "/test",
 				Status:  200,
-				Headers: map[string]string{"test": "value"},
+				Headers: map[string][]string{"test": {"value"}},
 				In:      nil,
 				Out:     []byte("test"),
 				CtIn:    "application/json",
@@ -206,7 +216,7 @@ func TestRunSingle(t *testing.T) {
 				Action:  "GET",
 				Url:     "/test",
 				Status:  200,
-				Headers: map[string]string{"test": "value"},
+				Headers: map[string][]string{"test": {"value"}},
 				In:      nil,
 				Out:     []byte("test1"),
 				CtIn:    "application/json",
@@ -229,7 +239,7 @@ func TestRunSingle(t *testing.T) {
 				Action:  "GET",
 				Url:     "/test",
 				Status:  200,
-				Headers: map[string]string{"test": "value"},
+				Headers: map[string][]string{"test": {"value"}},
 				In:      nil,
 				Out:     []byte("test"),
 				CtIn:    "application/json",
@@ -252,7 +262,7 @@ func TestRunSingle(t *testing.T) {
 				Action:  "GET",
 				Url:     "/test",
 				Status:  200,
-				Headers: map[string]string{"test": "value"},
+				Headers: map[string][]string{"test": {"value"}},
 				In:      nil,
 				Out:     nil,
 				CtIn:    "application/json",
@@ -275,7 +285,7 @@ func TestRunSingle(t *testing.T) {
 				Action:  "GET",
 				Url:     "/test",
 				Status:  200,
-				Headers: map[string]string{"test": "value"},
+				Headers: map[string][]string{"test": {"value"}},
 				In:      nil,
 				Out:     []byte("{\"something\":\"1\"}"),
 				CtIn:    "application/json",
@@ -298,7 +308,7 @@ func TestRunSingle(t *testing.T) {
 				Action:  "GET",
 				Url:     "/test",
 				Status:  200,
-				Headers: map[string]string{"test": "value"},
+				Headers: map[string][]string{"test": {"value"}},
 				In:      nil,
 				Out:     []byte("{\"something\":\"1\"}"),
 				CtIn:    "application/json",
@@ -321,7 +331,7 @@ func TestRunSingle(t *testing.T) {
 				Action:  "GET",
 				Url:     "/test",
 				Status:  200,
-				Headers: map[string]string{"test": "value"},
+				Headers: map[string][]string{"test": {"value"}},
 				In:      nil,
 				Out:     []byte("{\"somethingOnlyTheCOmparatorWillMatch\":\"1\"}"),
 				CtIn:    "application/json",
@@ -352,6 +362,78 @@ func TestRunSingle(t *testing.T) {
 			comparatorResult: nil,
 			expected:         nil,
 		},
+		{
+			input: testerconfig.UnitTest{
+				Action:  "HEAD",
+				Url:     "/test",
+				Status:  200,
+				Headers: map[string][]string{},
+				CtIn:    "application/json",
+				CtOut:   "application/json",
+			},
+			simulatedResponse: testerclient.Response{
+				StatusCode:  200,
+				Body:        ioutil.NopCloser(strings.NewReader("")),
+				ContentType: "application/json",
+				Headers:     map[string][]string{"Content-Length": {"42"}},
+			},
+			comparatorCapture: map[string]interface{}{},
+			endEnv:            map[string]string{},
+			expected:          nil,
+		},
+		{
+			input: testerconfig.UnitTest{
+				Action:  "HEAD",
+				Url:     "/test",
+				Status:  200,
+				Headers: map[string][]string{},
+				Out:     []byte("{}"),
+				CtIn:    "application/json",
+			},
+			simulatedResponse: testerclient.Response{
+				StatusCode:  200,
+				Body:        ioutil.NopCloser(strings.NewReader("")),
+				ContentType: "application/json",
+				Headers:     map[string][]string{},
+			},
+			comparatorCapture: map[string]interface{}{},
+			endEnv:            map[string]string{},
+			expected:          ErrBodylessResponse,
+		},
+		{
+			input: testerconfig.UnitTest{
+				Action:  "OPTIONS",
+				Url:     "/test",
+				Status:  204,
+				Headers: map[string][]string{"Origin": {"localhost"}},
+				Out:     []byte("{}"),
+				CtIn:    "application/json",
+			},
+			simulatedResponse: testerclient.Response{
+				StatusCode: 204,
+				Headers:    map[string][]string{},
+			},
+			comparatorCapture: map[string]interface{}{},
+			endEnv:            map[string]string{},
+			expected:          ErrBodylessResponse,
+		},
+		{
+			input: testerconfig.UnitTest{
+				Action:  "HEAD",
+				Url:     "/test",
+				Status:  200,
+				Headers: map[string][]string{},
+				CtIn:    "application/json",
+			},
+			simulatedResponse: testerclient.Response{
+				StatusCode: 404,
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+				Headers:    map[string][]string{},
+			},
+			comparatorCapture: map[string]interface{}{},
+			endEnv:            map[string]string{},
+			expected:          ErrWrongStatus,
+		},
 	}
 
 	for i, tt := range tests {
@@ -395,7 +477,7 @@ func TestRunSingle(t *testing.T) {
 					t.Fatalf("%d failed got %v exp %v ", i, fakeClient.lastRequest.Body, tt.input.In)
 				}
 			}
-			if fakeClient.lastRequest.Headers["Content-Type"] != tt.input.CtIn {
+			if !reflect.DeepEqual(fakeClient.lastRequest.Headers["Content-Type"], []string{tt.input.CtIn}) {
 				t.Fatalf("%d failed got %v exp %v ", i, fakeClient.lastRequest.Headers["Content-Type"], tt.input.CtIn)
 			}
 
@@ -404,7 +486,7 @@ func TestRunSingle(t *testing.T) {
 				if !ok {
 					t.Fatalf("%d should found %s ", i, k)
 				}
-				if fakeClient.lastRequest.Headers[k] != v {
+				if !reflect.DeepEqual(fakeClient.lastRequest.Headers[k], v) {
 					t.Fatalf("%d failed got %v exp %v ", i, fakeClient.lastRequest.Headers[k], v)
 				}
 			}
@@ -428,3 +510,519 @@ func TestRunSingle(t *testing.T) {
 	}
 
 }
+
+func TestRunSingleOutHeaders(t *testing.T) {
+	response := testerclient.Response{
+		StatusCode: 201,
+		Headers: map[string][]string{
+			"Location":      {"/articles/12"},
+			"Cache-Control": {"private, no-store"},
+			"Set-Cookie":    {"a=1", "b=2"},
+		},
+	}
+	tests := []struct {
+		outHeaders    map[string]string
+		absentHeaders []string
+		expected      error
+	}{
+		{outHeaders: map[string]string{"Location": "/articles/@number@", "cache-control": "@string@.contains('no-store')", "Set-Cookie": "b=2"}},
+		{outHeaders: map[string]string{"Location": "/articles/#id#"}},
+		{outHeaders: map[string]string{"Location": "/articles/@uuid@"}, expected: ErrWrongHeader},
+		{outHeaders: map[string]string{"ETag": "@string@"}, expected: ErrWrongHeader},
+		{absentHeaders: []string{"X-Powered-By"}},
+		{absentHeaders: []string{"Set-Cookie"}, expected: ErrUnexpectedHeader},
+	}
+
+	for i, tt := range tests {
+		unittester := New(&fakeClient{nexResponse: response}, &fakeComparator{}, &fakeFileOpener{})
+		unittester.Env()["id"] = "12"
+		err := unittester.RunSingle(testerconfig.UnitTest{
+			Action:        "POST",
+			Url:           "/articles",
+			Status:        201,
+			OutHeaders:    tt.outHeaders,
+			AbsentHeaders: tt.absentHeaders,
+		})
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+}
+
+func TestRunSingleCaptureHeaders(t *testing.T) {
+	response := testerclient.Response{
+		StatusCode: 201,
+		Headers: map[string][]string{
+			"Location": {"/articles/12"},
+			"Etag":     {`W/"abc"`},
+		},
+	}
+	tests := []struct {
+		captures map[string]testerconfig.HeaderCapture
+		expected error
+		endEnv   map[string]string
+	}{
+		{
+			captures: map[string]testerconfig.HeaderCapture{
+				"location": {Header: "Location"},
+				"id":       {Header: "Location", Regexp: `/articles/([0-9]+)`},
+				"etag":     {Header: "ETag"},
+				"path":     {Header: "Location", Regexp: `/[a-z]+`},
+			},
+			endEnv: map[string]string{"location": "/articles/12", "id": "12", "etag": `W/"abc"`, "path": "/articles"},
+		},
+		{
+			captures: map[string]testerconfig.HeaderCapture{"id": {Header: "Location", Regexp: `/users/([0-9]+)`}},
+			expected: ErrHeaderNoCapture,
+			endEnv:   map[string]string{},
+		},
+		{
+			captures: map[string]testerconfig.HeaderCapture{"date": {Header: "Last-Modified"}},
+			expected: ErrHeaderNoCapture,
+			endEnv:   map[string]string{},
+		},
+	}
+
+	for i, tt := range tests {
+		unittester := New(&fakeClient{nexResponse: response}, &fakeComparator{}, &fakeFileOpener{})
+		err := unittester.RunSingle(testerconfig.UnitTest{
+			Action:         "POST",
+			Url:            "/articles",
+			Status:         201,
+			CaptureHeaders: tt.captures,
+		})
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+		if !reflect.DeepEqual(unittester.Env(), tt.endEnv) {
+			t.Fatalf("%d failed got %v, exp %v", i, unittester.Env(), tt.endEnv)
+		}
+	}
+}
+
+func TestRunSingleOutCookies(t *testing.T) {
+	yes, no := true, false
+	response := testerclient.Response{
+		StatusCode: 200,
+		Headers: map[string][]string{
+			"Set-Cookie": {
+				"session=abc123; Path=/; HttpOnly; Secure; SameSite=Strict",
+				"remember=1; Max-Age=3600",
+				"old=; Expires=Thu, 01 Jan 1970 00:00:00 GMT",
+			},
+		},
+	}
+	tests := []struct {
+		outCookies    map[string]testerconfig.CookieAssertion
+		absentCookies []string
+		expected      error
+	}{
+		{outCookies: map[string]testerconfig.CookieAssertion{
+			"session":  {Value: "@string@.startsWith('abc')", HttpOnly: &yes, Secure: &yes, SameSite: "strict", Expires: "session"},
+			"remember": {Value: "#remember#", HttpOnly: &no, Expires: "@number@.greaterThan(3000)"},
+			"old":      {Expires: "0"},
+		}},
+		{outCookies: map[string]testerconfig.CookieAssertion{"session": {Value: "other"}}, expected: ErrWrongCookie},
+		{outCookies: map[string]testerconfig.CookieAssertion{"remember": {Secure: &yes}}, expected: ErrWrongCookie},
+		{outCookies: map[string]testerconfig.CookieAssertion{"remember": {SameSite: "Lax"}}, expected: ErrWrongCookie},
+		{outCookies: map[string]testerconfig.CookieAssertion{"remember": {Expires: "session"}}, expected: ErrWrongCookie},
+		{outCookies: map[string]testerconfig.CookieAssertion{"session": {Expires: "@number@"}}, expected: ErrWrongCookie},
+		{outCookies: map[string]testerconfig.CookieAssertion{"token": {}}, expected: ErrWrongCookie},
+		{absentCookies: []string{"token"}},
+		{absentCookies: []string{"session"}, expected: ErrUnexpectedCookie},
+	}
+
+	for i, tt := range tests {
+		unittester := New(&fakeClient{nexResponse: response}, &fakeComparator{}, &fakeFileOpener{})
+		unittester.Env()["remember"] = "1"
+		err := unittester.RunSingle(testerconfig.UnitTest{
+			Action:        "POST",
+			Url:           "/login",
+			Status:        200,
+			OutCookies:    tt.outCookies,
+			AbsentCookies: tt.absentCookies,
+		})
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+}
+
+func TestRunSingleClearCookies(t *testing.T) {
+	jar := testerclient.NewJar()
+	u, _ := url.Parse("http://localhost/")
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "abc"}})
+
+	unittester := New(&fakeClient{nexResponse: testerclient.Response{StatusCode: 200}}, &fakeComparator{}, &fakeFileOpener{})
+	unittester.Jar = jar
+	err := unittester.RunSingle(testerconfig.UnitTest{Action: "GET", Url: "/", Status: 200})
+	if err != nil || len(jar.Cookies(u)) != 1 {
+		t.Fatalf("failed got %v, %v", err, jar.Cookies(u))
+	}
+	err = unittester.RunSingle(testerconfig.UnitTest{Action: "GET", Url: "/", Status: 200, ClearCookies: true})
+	if err != nil || len(jar.Cookies(u)) != 0 {
+		t.Fatalf("failed got %v, %v", err, jar.Cookies(u))
+	}
+}
+
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestRunSingleClosesBody(t *testing.T) {
+	for i, status := range []int{200, 500} {
+		body := &closeTracker{Reader: strings.NewReader("{}")}
+		unittester := New(&fakeClient{nexResponse: testerclient.Response{StatusCode: status, Body: body}}, &fakeComparator{}, &fakeFileOpener{})
+		unittester.RunSingle(testerconfig.UnitTest{Action: "GET", Url: "/", Status: 200})
+		if !body.closed {
+			t.Fatalf("%d failed body with status %d is not closed", i, status)
+		}
+	}
+}
+
+func TestRunSingleOutTls(t *testing.T) {
+	state := &tls.ConnectionState{
+		Version:          tls.VersionTLS13,
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "api.internal", Organization: []string{"Acme"}}}},
+	}
+	tests := []struct {
+		state    *tls.ConnectionState
+		outTls   *testerconfig.TlsAssertion
+		expected error
+	}{
+		{state: state, outTls: &testerconfig.TlsAssertion{Version: "1.3", Subject: "CN=api.internal,O=Acme"}},
+		{state: state, outTls: &testerconfig.TlsAssertion{Subject: "CN=@string@,O=Acme"}},
+		{state: state, outTls: &testerconfig.TlsAssertion{Version: "1.2"}, expected: ErrWrongTls},
+		{state: state, outTls: &testerconfig.TlsAssertion{Subject: "CN=other"}, expected: ErrWrongTls},
+		{state: nil, outTls: &testerconfig.TlsAssertion{Version: "1.3"}, expected: ErrWrongTls},
+		{state: nil},
+	}
+
+	for i, tt := range tests {
+		unittester := New(&fakeClient{nexResponse: testerclient.Response{StatusCode: 200, TLS: tt.state}}, &fakeComparator{}, &fakeFileOpener{})
+		err := unittester.RunSingle(testerconfig.UnitTest{Action: "GET", Url: "/", Status: 200, OutTls: tt.outTls})
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+}
+
+func TestRunSingleOutRedirects(t *testing.T) {
+	response := testerclient.Response{
+		StatusCode: 200,
+		Redirects:  []testerclient.Redirect{{StatusCode: 302, Location: "/home?user=12"}, {StatusCode: 301, Location: "/dashboard"}},
+	}
+	tests := []struct {
+		outRedirects []testerconfig.RedirectAssertion
+		expected     error
+	}{
+		{outRedirects: nil},
+		{outRedirects: []testerconfig.RedirectAssertion{{Status: 302, Location: "/home?user=#id#"}, {Location: "/@string@"}}},
+		{outRedirects: []testerconfig.RedirectAssertion{{Status: 302}, {Status: 302}}, expected: ErrWrongRedirects},
+		{outRedirects: []testerconfig.RedirectAssertion{{Location: "/login"}, {}}, expected: ErrWrongRedirects},
+		{outRedirects: []testerconfig.RedirectAssertion{{Status: 302}}, expected: ErrWrongRedirects},
+		{outRedirects: []testerconfig.RedirectAssertion{}, expected: ErrWrongRedirects},
+	}
+
+	for i, tt := range tests {
+		unittester := New(&fakeClient{nexResponse: response}, &fakeComparator{}, &fakeFileOpener{})
+		unittester.Env()["id"] = "12"
+		err := unittester.RunSingle(testerconfig.UnitTest{Action: "GET", Url: "/login", Status: 200, MaxRedirects: 2, OutRedirects: tt.outRedirects})
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+}
+
+func TestRunSingleOutEncoding(t *testing.T) {
+	tests := []struct {
+		headers     map[string][]string
+		outEncoding string
+		expected    error
+	}{
+		{headers: map[string][]string{"Content-Encoding": {"gzip"}}, outEncoding: ""},
+		{headers: map[string][]string{"Content-Encoding": {"gzip"}}, outEncoding: "gzip"},
+		{headers: map[string][]string{"Content-Encoding": {"br"}}, outEncoding: "gzip", expected: ErrWrongEncoding},
+		{headers: map[string][]string{}, outEncoding: "gzip", expected: ErrWrongEncoding},
+		{headers: map[string][]string{}, outEncoding: "identity"},
+		{headers: map[string][]string{"Content-Encoding": {"gzip"}}, outEncoding: "identity", expected: ErrWrongEncoding},
+	}
+
+	for i, tt := range tests {
+		response := testerclient.Response{StatusCode: 200, Headers: tt.headers}
+		unittester := New(&fakeClient{nexResponse: response}, &fakeComparator{}, &fakeFileOpener{})
+		err := unittester.RunSingle(testerconfig.UnitTest{Action: "GET", Url: "/articles", Status: 200, Headers: map[string][]string{}, OutEncoding: tt.outEncoding})
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+}
+
+func TestRunSingleOutEncodingServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte(`{}`))
+		zw.Close()
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		acceptEncoding string
+		outEncoding    string
+		expected       error
+	}{
+		{acceptEncoding: "gzip", outEncoding: "gzip"},
+		{acceptEncoding: "identity", outEncoding: "identity", expected: ErrWrongEncoding},
+	}
+
+	for i, tt := range tests {
+		unittester := New(testerclient.New(server.URL), comparator.New("."), &fakeFileOpener{})
+		err := unittester.RunSingle(testerconfig.UnitTest{Action: "GET", Url: "/articles", Status: 200, Headers: map[string][]string{}, AcceptEncoding: tt.acceptEncoding, OutEncoding: tt.outEncoding})
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+	}
+}
+
+func TestBuildRequestEncoding(t *testing.T) {
+	ut := testerconfig.UnitTest{
+		Action:          "POST",
+		Url:             "/articles",
+		CtIn:            "application/json",
+		Headers:         map[string][]string{},
+		In:              []byte(`{"title":"#title#"}`),
+		AcceptEncoding:  "br",
+		ContentEncoding: "gzip",
+	}
+	request := BuildRequest(ut, map[string]string{})
+
+	expectedHeaders := map[string][]string{"Content-Type": {"application/json"}, "Accept-Encoding": {"br"}, "Content-Encoding": {"gzip"}}
+	if !reflect.DeepEqual(request.Headers, expectedHeaders) {
+		t.Fatalf("failed got %v exp %v", request.Headers, expectedHeaders)
+	}
+	r, err := gzip.NewReader(request.Body)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	body, _ := ioutil.ReadAll(r)
+	if string(body) != `{"title":"#title#"}` {
+		t.Fatalf("failed got %s", body)
+	}
+
+	ut.Headers = map[string][]string{"Accept-Encoding": {"gzip"}}
+	request = BuildRequest(ut, map[string]string{})
+	if !reflect.DeepEqual(request.Headers["Accept-Encoding"], []string{"gzip"}) {
+		t.Fatalf("failed got %v", request.Headers)
+	}
+}
+
+func TestBuildRequest(t *testing.T) {
+	tests := []struct {
+		input           testerconfig.UnitTest
+		expectedUrl     string
+		expectedHeaders map[string][]string
+	}{
+		{
+			input: testerconfig.UnitTest{
+				Action:  "GET",
+				Url:     "/articles/#id#",
+				CtIn:    "application/json",
+				Headers: map[string][]string{"X-#name#": {"#id#"}, "Accept": {"application/json", "text/plain"}},
+				Query:   map[string][]string{"tag": {"#tag#", "b"}, "#name#": {"1"}},
+			},
+			expectedUrl:     "/articles/1?tag=a&tag=b&trace=1",
+			expectedHeaders: map[string][]string{"X-trace": {"1"}, "Accept": {"application/json", "text/plain"}, "Content-Type": {"application/json"}},
+		},
+		{
+			input: testerconfig.UnitTest{
+				Action:  "POST",
+				Url:     "/articles?limit=10",
+				CtIn:    "application/json",
+				Headers: map[string][]string{"Content-Type": {"text/plain"}},
+				Query:   map[string][]string{"offset": {"0"}},
+			},
+			expectedUrl:     "/articles?limit=10&offset=0",
+			expectedHeaders: map[string][]string{"Content-Type": {"text/plain"}},
+		},
+	}
+
+	env := map[string]string{"id": "1", "tag": "a", "name": "trace"}
+	for i, tt := range tests {
+		request := BuildRequest(tt.input, env)
+		if request.Url != tt.expectedUrl {
+			t.Fatalf("%d failed got %s exp %s", i, request.Url, tt.expectedUrl)
+		}
+		if !reflect.DeepEqual(request.Headers, tt.expectedHeaders) {
+			t.Fatalf("%d failed got %v exp %v", i, request.Headers, tt.expectedHeaders)
+		}
+	}
+}
+
+func TestBuildRequestForm(t *testing.T) {
+	ut := testerconfig.UnitTest{
+		Action: "POST",
+		Url:    "/upload",
+		CtIn:   "application/json",
+		// replaced, it has no boundary
+		Headers: map[string][]string{"content-type": {"multipart/form-data"}},
+		Form: &testerconfig.Form{
+			Fields: map[string][]string{"title": {"#title#"}, "tags": {"a", "b"}},
+			Files:  []testerconfig.FormFile{{Name: "doc", Filename: "file.pdf", ContentType: "application/pdf", Path: "main/payloads/file.pdf", Content: []byte("%PDF")}},
+		},
+	}
+	request := BuildRequest(ut, map[string]string{"title": "hello"})
+
+	if len(request.Headers) != 1 || len(request.Headers["Content-Type"]) != 1 {
+		t.Fatalf("failed got headers %v", request.Headers)
+	}
+	_, params, err := mime.ParseMediaType(request.Headers["Content-Type"][0])
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	form, err := multipart.NewReader(request.Body, params["boundary"]).ReadForm(1024)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	expectedFields := map[string][]string{"title": {"hello"}, "tags": {"a", "b"}}
+	if !reflect.DeepEqual(form.Value, expectedFields) {
+		t.Fatalf("failed got %v exp %v", form.Value, expectedFields)
+	}
+	files := form.File["doc"]
+	if len(files) != 1 || files[0].Filename != "file.pdf" || files[0].Header.Get("Content-Type") != "application/pdf" {
+		t.Fatalf("failed got %#v", files)
+	}
+	f, _ := files[0].Open()
+	content, _ := ioutil.ReadAll(f)
+	if string(content) != "%PDF" {
+		t.Fatalf("failed got %s", content)
+	}
+
+	curl := CurlCommand(ut, request, "http://localhost/upload", map[string]string{"title": "hello"})
+	expectedCurl := `curl -i 'http://localhost/upload' --form-string 'tags=a' --form-string 'tags=b' --form-string 'title=hello' -F 'doc=@main/payloads/file.pdf;filename=file.pdf;type=application/pdf'`
+	if curl != expectedCurl {
+		t.Fatalf("failed got \n%s\n exp \n%s", curl, expectedCurl)
+	}
+}
+
+func TestBuildRequestFormUrlencoded(t *testing.T) {
+	ut := testerconfig.UnitTest{
+		Action:         "POST",
+		Url:            "/token",
+		CtIn:           "application/json",
+		Headers:        map[string][]string{},
+		FormUrlencoded: map[string][]string{"grant_type": {"password"}, "scope": {"read", "write"}, "username": {"#user#"}},
+	}
+	env := map[string]string{"user": "a&b"}
+	request := BuildRequest(ut, env)
+
+	if request.Headers["Content-Type"][0] != "application/x-www-form-urlencoded" {
+		t.Fatalf("failed got %v", request.Headers)
+	}
+	body, _ := ioutil.ReadAll(request.Body)
+	expected := "grant_type=password&scope=read&scope=write&username=a%26b"
+	if string(body) != expected {
+		t.Fatalf("failed got %s exp %s", body, expected)
+	}
+
+	curl := CurlCommand(ut, request, "http://localhost/token", env)
+	expectedCurl := `curl -i -X POST 'http://localhost/token' -H 'Content-Type: application/x-www-form-urlencoded' --data-binary 'grant_type=password&scope=read&scope=write&username=a%26b'`
+	if curl != expectedCurl {
+		t.Fatalf("failed got \n%s\n exp \n%s", curl, expectedCurl)
+	}
+}
+
+func TestRunSingleCurl(t *testing.T) {
+	tests := []struct {
+		input             testerconfig.UnitTest
+		env               map[string]string
+		simulatedResponse testerclient.Response
+		simulatedError    error
+		expected          string
+	}{
+		{
+			input: testerconfig.UnitTest{
+				Action:  "POST",
+				Url:     "/articles/#id#",
+				Status:  201,
+				Headers: map[string][]string{"Authorization": {"Bearer #token#"}},
+				In:      []byte(`{"title":"#title#"}`),
+				InFile:  "main/payloads/article.json",
+				CtIn:    "application/json",
+			},
+			env: map[string]string{"id": "1", "token": "abc", "title": "hello"},
+			simulatedResponse: testerclient.Response{
+				Url:        "http://localhost:3000/articles/1",
+				StatusCode: 500,
+			},
+			expected: `curl -i -X POST 'http://localhost:3000/articles/1' -H 'Authorization: Bearer abc' -H 'Content-Type: application/json' --data-binary '{"title":"hello"}'`,
+		},
+		{
+			input: testerconfig.UnitTest{
+				Action:  "GET",
+				Url:     "/articles",
+				Status:  200,
+				Headers: map[string][]string{},
+				CtIn:    "application/json",
+			},
+			env:            map[string]string{},
+			simulatedError: fmt.Errorf("connection refused"),
+			expected:       `curl -i '/articles' -H 'Content-Type: application/json'`,
+		},
+	}
+
+	for i, tt := range tests {
+		fakeClient := &fakeClient{
+			nexResponse: tt.simulatedResponse,
+			nextError:   tt.simulatedError,
+		}
+		unittester := New(fakeClient, &fakeComparator{}, &fakeFileOpener{})
+		for k, v := range tt.env {
+			unittester.Env()[k] = v
+		}
+
+		err := unittester.RunSingle(tt.input)
+		var utErr *UnitTesterError
+		if !errors.As(err, &utErr) {
+			t.Fatalf("%d failed got %v, exp an UnitTesterError", i, err)
+		}
+		if utErr.Curl != tt.expected {
+			t.Fatalf("%d failed got \n%s\n exp \n%s", i, utErr.Curl, tt.expected)
+		}
+		if !strings.Contains(utErr.Error(), tt.expected) {
+			t.Fatalf("%d failed curl is missing from %s", i, utErr.Error())
+		}
+	}
+}
+
+type endlessBody struct {
+	read   int64
+	closed bool
+}
+
+func (e *endlessBody) Read(p []byte) (int, error) {
+	e.read += int64(len(p))
+	return len(p), nil
+}
+
+func (e *endlessBody) Close() error {
+	e.closed = true
+	return nil
+}
+
+func TestCloseBodyEndless(t *testing.T) {
+	body := &endlessBody{}
+	closeBody(body)
+	if !body.closed {
+		t.Fatalf("failed the body isn't closed")
+	}
+	if body.read > maxDrain+32<<10 {
+		t.Fatalf("failed %d bytes read", body.read)
+	}
+}
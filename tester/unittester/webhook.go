@@ -0,0 +1,103 @@
+package unittester
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerclient"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"github.com/madelyne-io/madelyne/tester/testerstub"
+	"github.com/madelyne-io/madelyne/tester/testerwebhook"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrNoWebhookReceiver = fmt.Errorf("No webhook receiver started")
+	ErrWrongSignature    = fmt.Errorf("Wrong webhook signature")
+)
+
+var signatureHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+func (t *UnitTester) runWebhook(ut testerconfig.UnitTest) error {
+	if t.Webhooks == nil {
+		return ErrorIn(ut, nil, ErrNoWebhookReceiver)
+	}
+	timeout := ut.Timeout
+	if timeout == 0 {
+		timeout = testerconfig.DefaultTimeout
+	}
+	u := strings.TrimPrefix(ReplaceStringWithEnvValue(ut.Url, t.Environment), t.Webhooks.Url())
+	c, err := t.Webhooks.Expect(func(c testerwebhook.Callback) bool {
+		return u == "" || testerstub.MatchUrl(u, c.Path, c.Url)
+	}, timeout)
+	if err != nil {
+		return ErrorIn(ut, callbacksResult(t.Webhooks.Pending()), err)
+	}
+
+	r := testerclient.Response{
+		Url:         c.Url,
+		ContentType: http.Header(c.Headers).Get("Content-Type"),
+		Headers:     c.Headers,
+		Body:        ioutil.NopCloser(bytes.NewReader(c.Body)),
+	}
+	utErr := checkHeaders(ut, r, t.Environment)
+	if utErr != nil {
+		return utErr
+	}
+	if ut.Signature != nil {
+		err = checkSignature(*ut.Signature, c, t.Environment)
+		if err != nil {
+			return ErrorIn(ut, c.Body, err)
+		}
+	}
+	if ut.Out != nil {
+		ctOut := ut.CtOut
+		if ctOut == "" {
+			ctOut = "application/json"
+		}
+		utErr = t.compareBody(r.Body, ut.Out, ctOut, ut.Pcre)
+		if utErr != nil {
+			utErr.Ut = ut
+			return utErr
+		}
+	}
+	return t.captureHeaders(ut, r)
+}
+
+// checkSignature compares the signature header with the HMAC of the body.
+func checkSignature(s testerconfig.SignatureAssertion, c testerwebhook.Callback, env map[string]string) error {
+	got := http.Header(c.Headers).Get(s.Header)
+	if got == "" {
+		return fmt.Errorf("%w: %s is missing", ErrWrongSignature, s.Header)
+	}
+	mac := hmac.New(signatureHashes[s.Algorithm], []byte(ReplaceStringWithEnvValue(s.Secret, env)))
+	mac.Write(c.Body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if s.Encoding == "base64" {
+		expected = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	if !hmac.Equal([]byte(got), []byte(s.Prefix+expected)) {
+		return fmt.Errorf("%w: %s is %s expected %s", ErrWrongSignature, s.Header, got, s.Prefix+expected)
+	}
+	return nil
+}
+
+func callbacksResult(callbacks []testerwebhook.Callback) []byte {
+	lines := make([]string, 0, len(callbacks))
+	for _, c := range callbacks {
+		lines = append(lines, fmt.Sprintf("%s %s\n%s", c.Method, c.Url, c.Body))
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,108 @@
+package unittester
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/madelyne-io/madelyne/comparator"
+	"github.com/madelyne-io/madelyne/matcher"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"github.com/madelyne-io/madelyne/tester/testerwebhook"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSingleWebhook(t *testing.T) {
+	receiver, err := testerwebhook.Start(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer receiver.Close()
+
+	body := `{"event":"order.paid","order":"o_1","payment":"pay_12"}`
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(body))
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	send := func(path string) {
+		req, _ := http.NewRequest("POST", receiver.Url()+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Signature", signature)
+		req.Header.Set("X-Delivery", "d_42")
+		r, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		r.Body.Close()
+	}
+
+	validSignature := &testerconfig.SignatureAssertion{Header: "X-Signature", Secret: "#secret#", Algorithm: "sha256", Encoding: "hex", Prefix: "sha256="}
+	tests := []struct {
+		ut          testerconfig.UnitTest
+		expected    error
+		expectedEnv map[string]string
+	}{
+		{
+			ut: testerconfig.UnitTest{
+				Url:            "#webhook_url#/orders",
+				Out:            []byte(`{"event":"order.paid","order":"#order#","payment":"#payment={{@string@}}"}`),
+				OutHeaders:     map[string]string{"X-Delivery": "@string@.startsWith('d_')"},
+				CaptureHeaders: map[string]testerconfig.HeaderCapture{"delivery": {Header: "X-Delivery"}},
+				Signature:      validSignature,
+			},
+			expectedEnv: map[string]string{"order": "o_1", "secret": "s3cret", "payment": "pay_12", "delivery": "d_42"},
+		},
+		{
+			ut:       testerconfig.UnitTest{Url: "/orders", Out: []byte(`{"event":"order.refunded","order":"#order#","payment":"@string@"}`)},
+			expected: matcher.ErrInvalidValue,
+		},
+		{
+			ut:       testerconfig.UnitTest{Url: "/orders", Signature: &testerconfig.SignatureAssertion{Header: "X-Signature", Secret: "other", Algorithm: "sha256", Encoding: "hex", Prefix: "sha256="}},
+			expected: ErrWrongSignature,
+		},
+		{
+			ut:       testerconfig.UnitTest{Url: "/orders", Signature: &testerconfig.SignatureAssertion{Header: "X-Hub-Signature", Secret: "s3cret", Algorithm: "sha256", Encoding: "hex"}},
+			expected: ErrWrongSignature,
+		},
+		{
+			ut:       testerconfig.UnitTest{Url: "/orders", OutHeaders: map[string]string{"X-Delivery": "d_1"}},
+			expected: ErrWrongHeader,
+		},
+		{
+			ut:       testerconfig.UnitTest{Url: "/refunds", Timeout: 50 * time.Millisecond},
+			expected: testerwebhook.ErrNoCallback,
+		},
+	}
+
+	for i, tt := range tests {
+		unittester := New(&fakeClient{}, comparator.New("."), &fakeFileOpener{})
+		unittester.Webhooks = receiver
+		unittester.Env()["order"] = "o_1"
+		unittester.Env()["secret"] = "s3cret"
+		if tt.expectedEnv != nil {
+			unittester.Env()["webhook_url"] = receiver.Url()
+			tt.expectedEnv["webhook_url"] = receiver.Url()
+		}
+		go send("/orders")
+		tt.ut.Action = "EXPECT_WEBHOOK"
+		err := unittester.RunSingle(tt.ut)
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+		if tt.expectedEnv != nil && !reflect.DeepEqual(unittester.Env(), tt.expectedEnv) {
+			t.Fatalf("%d failed got %v, exp %v", i, unittester.Env(), tt.expectedEnv)
+		}
+		receiver.Reset()
+	}
+
+	unittester := New(&fakeClient{}, comparator.New("."), &fakeFileOpener{})
+	err = unittester.RunSingle(testerconfig.UnitTest{Action: "EXPECT_WEBHOOK"})
+	if !errors.Is(err, ErrNoWebhookReceiver) {
+		t.Fatalf("failed got %v", err)
+	}
+}
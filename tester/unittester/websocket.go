@@ -0,0 +1,69 @@
+package unittester
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/madelyne-io/madelyne/tester/testerclient"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"unicode/utf8"
+)
+
+var (
+	ErrNoSocket  = fmt.Errorf("WebSocket steps can only run in scenarios")
+	ErrNoMessage = fmt.Errorf("No message received")
+)
+
+func (t *UnitTester) runSocket(ut testerconfig.UnitTest) error {
+	if t.Socket == nil {
+		return ErrorIn(ut, nil, ErrNoSocket)
+	}
+
+	switch ut.Action {
+	case "WS_CONNECT":
+		r, err := t.Socket.Connect(BuildRequest(ut, t.Environment))
+		if err == nil && r.Body != nil {
+			defer closeBody(r.Body)
+		}
+		utErr := t.checkResponse(ut, r, err)
+		if utErr != nil {
+			return utErr
+		}
+		return t.captureHeaders(ut, r)
+	case "WS_SEND":
+		err := t.Socket.Send(ut.In, !utf8.Valid(ut.In))
+		if err != nil {
+			return ErrorIn(ut, nil, err)
+		}
+	case "WS_EXPECT":
+		timeout := ut.Timeout
+		if timeout == 0 {
+			timeout = testerconfig.DefaultTimeout
+		}
+		data, err := t.Socket.Receive(timeout)
+		if errors.Is(err, testerclient.ErrSocketNotConnected) {
+			return ErrorIn(ut, nil, err)
+		}
+		if err != nil {
+			return ErrorIn(ut, nil, fmt.Errorf("%w: %v", ErrNoMessage, err))
+		}
+		if ut.Out == nil {
+			return nil
+		}
+		ctOut := ut.CtOut
+		if ctOut == "" {
+			ctOut = "application/json"
+		}
+		utErr := t.compareBody(bytes.NewReader(data), ut.Out, ctOut, ut.Pcre)
+		if utErr != nil {
+			utErr.Ut = ut
+			return utErr
+		}
+	case "WS_CLOSE":
+		err := t.Socket.Close()
+		if err != nil {
+			return ErrorIn(ut, nil, err)
+		}
+	}
+	return nil
+}
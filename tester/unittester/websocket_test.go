@@ -0,0 +1,76 @@
+package unittester
+
+import (
+	"errors"
+	"github.com/gorilla/websocket"
+	"github.com/madelyne-io/madelyne/comparator"
+	"github.com/madelyne-io/madelyne/matcher"
+	"github.com/madelyne-io/madelyne/tester/testerclient"
+	"github.com/madelyne-io/madelyne/tester/testerconfig"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunSingleSocket(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, http.Header{"X-Session": {"s1"}})
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(websocket.TextMessage, data)
+		}
+	}))
+	defer server.Close()
+
+	socket := testerclient.NewSocket(server.URL, nil, nil)
+	defer socket.Close()
+	tests := []struct {
+		ut       testerconfig.UnitTest
+		expected error
+	}{
+		{ut: testerconfig.UnitTest{Action: "WS_SEND", In: []byte(`{}`)}, expected: testerclient.ErrSocketNotConnected},
+		{ut: testerconfig.UnitTest{Action: "WS_CONNECT", Url: "/ws", Status: 200, Headers: map[string][]string{}}, expected: ErrWrongStatus},
+		{ut: testerconfig.UnitTest{Action: "WS_CONNECT", Url: "/ws", Status: 101, Headers: map[string][]string{}, CaptureHeaders: map[string]testerconfig.HeaderCapture{"session": {Header: "X-Session"}}}},
+		{ut: testerconfig.UnitTest{Action: "WS_SEND", In: []byte(`{"id":12,"session":"#session#"}`)}},
+		{ut: testerconfig.UnitTest{Action: "WS_EXPECT", Out: []byte(`{"id":"#id={{@integer@}}","session":"#session#"}`)}},
+		{ut: testerconfig.UnitTest{Action: "WS_SEND", In: []byte(`{"id":13}`)}},
+		{ut: testerconfig.UnitTest{Action: "WS_EXPECT", Out: []byte(`{"id":12}`)}, expected: matcher.ErrInvalidValue},
+		{ut: testerconfig.UnitTest{Action: "WS_EXPECT", Timeout: 10 * time.Millisecond}, expected: ErrNoMessage},
+		{ut: testerconfig.UnitTest{Action: "WS_CLOSE"}},
+		{ut: testerconfig.UnitTest{Action: "WS_EXPECT"}, expected: testerclient.ErrSocketNotConnected},
+	}
+
+	env := map[string]string{}
+	for i, tt := range tests {
+		unittester := New(&fakeClient{}, comparator.New("."), &fakeFileOpener{})
+		unittester.Socket = socket
+		for k, v := range env {
+			unittester.Env()[k] = v
+		}
+		err := unittester.RunSingle(tt.ut)
+		if !errors.Is(err, tt.expected) {
+			t.Fatalf("%d failed got %v, exp %v", i, err, tt.expected)
+		}
+		env = unittester.Env()
+	}
+	expectedEnv := map[string]string{"session": "s1", "id": "12"}
+	if !reflect.DeepEqual(env, expectedEnv) {
+		t.Fatalf("failed got %v, exp %v", env, expectedEnv)
+	}
+
+	unittester := New(&fakeClient{}, comparator.New("."), &fakeFileOpener{})
+	err := unittester.RunSingle(testerconfig.UnitTest{Action: "WS_CLOSE"})
+	if !errors.Is(err, ErrNoSocket) {
+		t.Fatalf("failed got %v, exp %v", err, ErrNoSocket)
+	}
+}